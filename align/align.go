@@ -0,0 +1,360 @@
+// Package align aligns protein sequences pairwise with substitution matrices and affine gap
+// penalties and searches sequence databases for local alignments.
+package align
+
+import (
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/protein"
+)
+
+// Mode selects which parts of the sequences an alignment has to cover.
+type Mode int
+
+const (
+	Global     Mode = iota // Needleman-Wunsch, both sequences end to end
+	Local                  // Smith-Waterman, the best scoring pair of segments
+	SemiGlobal             // Overlap alignment, gaps at the ends of either sequence are free
+)
+
+// String returns the name of the mode.
+func (m Mode) String() string {
+	switch m {
+	case Local:
+		return "local"
+	case SemiGlobal:
+		return "semi-global"
+	}
+	return "global"
+}
+
+// Scoring holds a substitution matrix and affine gap penalties. A gap of k residues costs
+// GapOpen + k*GapExtend, as in BLAST.
+type Scoring struct {
+	Matrix    *Matrix
+	GapOpen   int
+	GapExtend int
+}
+
+// DefaultScoring is the BLASTP default of BLOSUM62 with gap costs 11 and 1.
+var DefaultScoring = Scoring{Matrix: &BLOSUM62, GapOpen: 11, GapExtend: 1}
+
+// gap returns the score of a gap of k residues.
+func (s Scoring) gap(k int) int {
+	if k == 0 {
+		return 0
+	}
+	return -(s.GapOpen + k*s.GapExtend)
+}
+
+// OpType is the kind of an alignment column.
+type OpType byte
+
+const (
+	Match     OpType = 'M' // A query residue aligned to a target residue, identical or not
+	Insertion OpType = 'I' // A query residue aligned to a gap in the target
+	Deletion  OpType = 'D' // A target residue aligned to a gap in the query
+)
+
+// Op is a run of alignment columns of the same type.
+type Op struct {
+	Type OpType
+	Len  int
+}
+
+// Alignment is a pairwise alignment. The aligned segments are query[QueryStart:QueryEnd] and
+// target[TargetStart:TargetEnd]; global alignments cover both sequences.
+type Alignment struct {
+	Score       int
+	QueryStart  int
+	QueryEnd    int
+	TargetStart int
+	TargetEnd   int
+	Query       string // Aligned query with '-' for gaps
+	Target      string // Aligned target with '-' for gaps
+	Midline     string // '|' for identities, '+' for positive scores, ' ' otherwise
+	Ops         []Op
+	Identities  int
+	Positives   int // Columns with a positive substitution score, including identities
+	Gaps        int // Gap columns
+}
+
+// Length returns the number of alignment columns.
+func (a Alignment) Length() int {
+	return len(a.Query)
+}
+
+// Identity returns the percentage of columns with identical residues.
+func (a Alignment) Identity() float64 {
+	return percent(a.Identities, a.Length())
+}
+
+// Similarity returns the percentage of columns with a positive substitution score.
+func (a Alignment) Similarity() float64 {
+	return percent(a.Positives, a.Length())
+}
+
+// GapPercent returns the percentage of gap columns.
+func (a Alignment) GapPercent() float64 {
+	return percent(a.Gaps, a.Length())
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+// Cigar returns the operations in CIGAR notation, e.g. 10M2I5M.
+func (a Alignment) Cigar() string {
+	var sb strings.Builder
+	for _, op := range a.Ops {
+		sb.WriteString(strconv.Itoa(op.Len))
+		sb.WriteByte(byte(op.Type))
+	}
+	return sb.String()
+}
+
+// Align aligns query and target in the given mode with a full dynamic programming matrix, using
+// the affine gap recurrences of Gotoh (1982). It takes time and memory proportional to the
+// product of the sequence lengths; AlignLinear needs only linear memory.
+func Align(query, target []protein.Protein, mode Mode, scoring Scoring) Alignment {
+	n, m := len(query), len(target)
+	width := m + 1
+
+	// h, x and y hold the best scores of the current row ending in any state, in a query residue
+	// against a gap (Insertion) and in a target residue against a gap (Deletion). trace records
+	// per cell where h came from and whether x and y extended a gap.
+	trace := make([]byte, (n+1)*width)
+	h := make([]int, width)
+	x := make([]int, width)
+	prevH := make([]int, width)
+	prevX := make([]int, width)
+
+	for j := 1; j <= m; j++ {
+		x[j] = minScore
+		if mode == Global {
+			h[j] = scoring.gap(j)
+			trace[j] = fromY
+			if j > 1 {
+				trace[j] |= extendY
+			}
+		}
+	}
+	bestScore, bestI, bestJ := h[0], 0, 0
+	if mode != Global {
+		bestScore = minScore
+	}
+
+	for i := 1; i <= n; i++ {
+		h, prevH = prevH, h
+		x, prevX = prevX, x
+		row := trace[i*width:]
+
+		h[0], x[0] = 0, minScore
+		if mode == Global {
+			h[0] = scoring.gap(i)
+			row[0] = fromX
+			if i > 1 {
+				row[0] |= extendX
+			}
+		}
+		y := minScore
+		for j := 1; j <= m; j++ {
+			var t byte
+
+			// Insertion: a query residue against a gap, opened from h or extended from x
+			x[j] = prevH[j] - scoring.GapOpen - scoring.GapExtend
+			if ext := prevX[j] - scoring.GapExtend; ext > x[j] {
+				x[j] = ext
+				t |= extendX
+			}
+			// Deletion: a target residue against a gap, opened from h or extended from y
+			open := h[j-1] - scoring.GapOpen - scoring.GapExtend
+			if ext := y - scoring.GapExtend; ext > open {
+				y = ext
+				t |= extendY
+			} else {
+				y = open
+			}
+
+			best := prevH[j-1] + scoring.Matrix.Score(query[i-1], target[j-1])
+			t |= fromM
+			if x[j] > best {
+				best = x[j]
+				t = t&^fromMask | fromX
+			}
+			if y > best {
+				best = y
+				t = t&^fromMask | fromY
+			}
+			if mode == Local && best <= 0 {
+				best = 0
+				t &^= fromMask
+			}
+			h[j] = best
+			row[j] = t
+
+			if mode == Local && best > bestScore {
+				bestScore, bestI, bestJ = best, i, j
+			}
+		}
+		if mode == SemiGlobal && h[m] > bestScore && m > 0 {
+			bestScore, bestI, bestJ = h[m], i, m
+		}
+	}
+
+	switch mode {
+	case Global:
+		bestScore, bestI, bestJ = h[m], n, m
+	case SemiGlobal:
+		for j := 0; j <= m; j++ {
+			if h[j] > bestScore {
+				bestScore, bestI, bestJ = h[j], n, j
+			}
+		}
+	case Local:
+		if bestScore <= 0 {
+			bestScore, bestI, bestJ = 0, 0, 0
+		}
+	}
+
+	ops, startI, startJ := traceback(trace, width, bestI, bestJ, mode)
+	return newAlignment(query, target, startI, startJ, ops, bestScore, scoring)
+}
+
+// minScore stands for minus infinity, leaving room to subtract gap penalties without overflow.
+const minScore = -1 << 40
+
+// Bits of a trace cell: the state h came from, or none for the start of a local alignment, and
+// whether the insertion and deletion states extended an open gap.
+const (
+	fromM    byte = 1
+	fromX    byte = 2
+	fromY    byte = 3
+	fromMask byte = 3
+	extendX  byte = 4
+	extendY  byte = 8
+)
+
+// traceback follows the trace matrix back from cell (i, j) and returns the alignment operations
+// and the cell the alignment starts at.
+func traceback(trace []byte, width, i, j int, mode Mode) ([]Op, int, int) {
+	var reversed []OpType
+	var state byte // The state of the current cell, 0 while in h, the best of the three states
+	for i > 0 || j > 0 {
+		t := trace[i*width+j]
+		if state == 0 {
+			if mode == SemiGlobal && (i == 0 || j == 0) {
+				break
+			}
+			if state = t & fromMask; state == 0 {
+				break
+			}
+		}
+		switch state {
+		case fromM:
+			reversed = append(reversed, Match)
+			i, j = i-1, j-1
+			state = 0
+		case fromX:
+			reversed = append(reversed, Insertion)
+			if t&extendX == 0 {
+				state = 0
+			}
+			i--
+		case fromY:
+			reversed = append(reversed, Deletion)
+			if t&extendY == 0 {
+				state = 0
+			}
+			j--
+		}
+	}
+
+	var ops []Op
+	for k := len(reversed) - 1; k >= 0; k-- {
+		ops = appendOp(ops, reversed[k], 1)
+	}
+	return ops, i, j
+}
+
+// appendOp appends n columns of type t, merging them with the last run.
+func appendOp(ops []Op, t OpType, n int) []Op {
+	if n == 0 {
+		return ops
+	}
+	if len(ops) > 0 && ops[len(ops)-1].Type == t {
+		ops[len(ops)-1].Len += n
+		return ops
+	}
+	return append(ops, Op{Type: t, Len: n})
+}
+
+// newAlignment builds the aligned strings and statistics of the operations starting at query[i]
+// and target[j].
+func newAlignment(query, target []protein.Protein, i, j int, ops []Op, score int, scoring Scoring) Alignment {
+	a := Alignment{Score: score, QueryStart: i, TargetStart: j, Ops: ops}
+	var q, t, mid strings.Builder
+	for _, op := range ops {
+		for k := 0; k < op.Len; k++ {
+			switch op.Type {
+			case Match:
+				q.WriteString(protein.ToString(query[i : i+1]))
+				t.WriteString(protein.ToString(target[j : j+1]))
+				switch {
+				case query[i] == target[j]:
+					mid.WriteByte('|')
+					a.Identities++
+					a.Positives++
+				case scoring.Matrix.Score(query[i], target[j]) > 0:
+					mid.WriteByte('+')
+					a.Positives++
+				default:
+					mid.WriteByte(' ')
+				}
+				i, j = i+1, j+1
+			case Insertion:
+				q.WriteString(protein.ToString(query[i : i+1]))
+				t.WriteByte('-')
+				mid.WriteByte(' ')
+				a.Gaps++
+				i++
+			case Deletion:
+				q.WriteByte('-')
+				t.WriteString(protein.ToString(target[j : j+1]))
+				mid.WriteByte(' ')
+				a.Gaps++
+				j++
+			}
+		}
+	}
+	a.QueryEnd, a.TargetEnd = i, j
+	a.Query, a.Target, a.Midline = q.String(), t.String(), mid.String()
+	return a
+}
+
+// Rescore computes the score of the operations of an alignment from its sequences, which can be
+// used to check an alignment read from elsewhere.
+func Rescore(query, target []protein.Protein, a Alignment, scoring Scoring) int {
+	score := 0
+	i, j := a.QueryStart, a.TargetStart
+	for _, op := range a.Ops {
+		switch op.Type {
+		case Match:
+			for k := 0; k < op.Len; k++ {
+				score += scoring.Matrix.Score(query[i+k], target[j+k])
+			}
+			i, j = i+op.Len, j+op.Len
+		case Insertion:
+			score += scoring.gap(op.Len)
+			i += op.Len
+		case Deletion:
+			score += scoring.gap(op.Len)
+			j += op.Len
+		}
+	}
+	return score
+}
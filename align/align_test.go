@@ -0,0 +1,85 @@
+package align
+
+import (
+	"math/rand"
+	"testing"
+
+	"gopher-proteinlab/protein"
+)
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		query, target string
+		mode          Mode
+		score         int
+		cigar         string
+		alignedQuery  string
+		alignedTarget string
+	}{
+		{"HEAGAWGHEE", "HEAGAWGHEE", Global, 62, "10M", "HEAGAWGHEE", "HEAGAWGHEE"},
+		// The four missing residues form one gap of cost 11 + 4
+		{"MKTAYIAKQRQISFVKSHFSRQ", "MKTAYIAKQRQISFVKSQ", Global, 86 - 15, "17M4I1M", "MKTAYIAKQRQISFVKSHFSRQ", "MKTAYIAKQRQISFVKS----Q"},
+		{"PAWHEAE", "HEAGAWGHEE", Local, 17, "3M", "HEA", "HEA"},
+		{"WWWWHEAGAWGHEE", "HEAGAWGHEEKKKK", SemiGlobal, 62, "10M", "HEAGAWGHEE", "HEAGAWGHEE"},
+		{"", "HEAG", Global, -15, "4D", "----", "HEAG"},
+		{"WWW", "", Local, 0, "", "", ""},
+	}
+	for _, test := range tests {
+		query, target := protein.ToProteins(test.query), protein.ToProteins(test.target)
+		for _, a := range []Alignment{Align(query, target, test.mode, DefaultScoring), AlignLinear(query, target, test.mode, DefaultScoring)} {
+			if a.Score != test.score || Rescore(query, target, a, DefaultScoring) != a.Score {
+				t.Errorf("Error: %s alignment of %s and %s scored %d, expected: %d\n%s\n%s", test.mode, test.query, test.target, a.Score, test.score, a.Query, a.Target)
+			}
+			if a.Cigar() != test.cigar {
+				t.Errorf("Error: %s alignment Cigar() = %s, expected: %s", test.mode, a.Cigar(), test.cigar)
+			}
+			if a.Query != test.alignedQuery || a.Target != test.alignedTarget {
+				t.Errorf("Error: %s alignment = %s/%s, expected: %s/%s", test.mode, a.Query, a.Target, test.alignedQuery, test.alignedTarget)
+			}
+		}
+	}
+
+	a := Align(protein.ToProteins("HEAGAWGHEKW"), protein.ToProteins("HEAGSWGHEW"), Global, DefaultScoring)
+	if a.Query != "HEAGAWGHEKW" || a.Target != "HEAGSWGHE-W" || a.Midline != "||||+|||| |" || a.Cigar() != "9M1I1M" {
+		t.Errorf("Error: Align() = %s/%s/%s %s", a.Query, a.Midline, a.Target, a.Cigar())
+	}
+	if a.Identities != 9 || a.Positives != 10 || a.Gaps != 1 || a.Identity() != 900.0/11 || a.GapPercent() != 100.0/11 {
+		t.Errorf("Error: Align() statistics %d, %d, %d", a.Identities, a.Positives, a.Gaps)
+	}
+	if a.QueryStart != 0 || a.QueryEnd != 11 || a.TargetStart != 0 || a.TargetEnd != 10 {
+		t.Errorf("Error: Align() ranges %d-%d, %d-%d", a.QueryStart, a.QueryEnd, a.TargetStart, a.TargetEnd)
+	}
+}
+
+// randomProtein returns a random sequence of the 20 standard amino acids.
+func randomProtein(r *rand.Rand, n int) []protein.Protein {
+	const residues = "ACDEFGHIKLMNPQRSTVWY"
+	seq := make([]protein.Protein, n)
+	for i := range seq {
+		seq[i] = protein.Protein(residues[r.Intn(len(residues))])
+	}
+	return seq
+}
+
+func TestAlignLinear(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	scorings := []Scoring{DefaultScoring, {Matrix: &BLOSUM62, GapOpen: 0, GapExtend: 4}, {Matrix: &BLOSUM62, GapOpen: 5, GapExtend: 2}}
+	for k := 0; k < 300; k++ {
+		query := randomProtein(r, r.Intn(40))
+		target := randomProtein(r, r.Intn(40))
+		if k%3 == 0 {
+			// Related sequences give alignments with long gaps
+			target = append(append(append([]protein.Protein(nil), query[:len(query)/3]...), target...), query[len(query)/2:]...)
+		}
+		scoring := scorings[k%len(scorings)]
+		for _, mode := range []Mode{Global, Local, SemiGlobal} {
+			full := Align(query, target, mode, scoring)
+			linear := AlignLinear(query, target, mode, scoring)
+			if full.Score != linear.Score || Rescore(query, target, full, scoring) != full.Score {
+				t.Fatalf("Error: %s alignment of %s and %s scored %d (rescored %d) and %d linear\n%s\n%s\n\n%s\n%s", mode,
+					protein.ToString(query), protein.ToString(target), full.Score, Rescore(query, target, full, scoring), linear.Score,
+					full.Query, full.Target, linear.Query, linear.Target)
+			}
+		}
+	}
+}
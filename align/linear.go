@@ -0,0 +1,182 @@
+package align
+
+import "gopher-proteinlab/protein"
+
+// AlignLinear aligns query and target like Align but in memory proportional to the length of the
+// target, for sequences too long for a full matrix. The aligned region is found with score-only
+// passes and aligned with the divide and conquer algorithm of Myers and Miller (1988), which
+// takes about twice the time of Align. Both return alignments of the same score, though they may
+// choose different ones among equally good alignments.
+func AlignLinear(query, target []protein.Protein, mode Mode, scoring Scoring) Alignment {
+	qStart, qEnd, tStart, tEnd := 0, len(query), 0, len(target)
+	switch mode {
+	case Local:
+		score, i, j := bestCell(query, target, scoring, true, false)
+		if score <= 0 {
+			return newAlignment(query, target, 0, 0, nil, 0, scoring)
+		}
+		_, ri, rj := bestCell(reverse(query[:i]), reverse(target[:j]), scoring, true, false)
+		qStart, qEnd, tStart, tEnd = i-ri, i, j-rj, j
+	case SemiGlobal:
+		_, i, j := bestCell(query, target, scoring, false, true)
+		_, ri, rj := bestCell(reverse(query[:i]), reverse(target[:j]), scoring, false, false)
+		qStart, qEnd, tStart, tEnd = i-ri, i, j-rj, j
+	}
+
+	l := linear{scoring: scoring}
+	l.diff(query[qStart:qEnd], target[tStart:tEnd], scoring.GapOpen, scoring.GapOpen)
+	a := newAlignment(query, target, qStart, tStart, l.ops, 0, scoring)
+	a.Score = Rescore(query, target, a, scoring)
+	return a
+}
+
+// reverse returns a reversed copy of a sequence.
+func reverse(seq []protein.Protein) []protein.Protein {
+	reversed := make([]protein.Protein, len(seq))
+	for i, aa := range seq {
+		reversed[len(seq)-1-i] = aa
+	}
+	return reversed
+}
+
+// bestCell returns the score and end cell of the best alignment of a against b, keeping a single
+// row of the matrix. Local alignments start and end anywhere. Other alignments end in the last
+// row or column, and start at the origin or, when freeStart is set, anywhere in the first row or
+// column.
+func bestCell(a, b []protein.Protein, scoring Scoring, local, freeStart bool) (int, int, int) {
+	n, m := len(a), len(b)
+	h := make([]int, m+1)
+	x := make([]int, m+1)
+	for j := 1; j <= m; j++ {
+		x[j] = minScore
+		if !local && !freeStart {
+			h[j] = scoring.gap(j)
+		}
+	}
+	best, bestI, bestJ := 0, 0, 0
+	if !local {
+		best, bestJ = h[m], m
+	}
+	for i := 1; i <= n; i++ {
+		diag := h[0]
+		if !local && !freeStart {
+			h[0] = scoring.gap(i)
+		}
+		y := minScore
+		for j := 1; j <= m; j++ {
+			x[j] = max(h[j]-scoring.GapOpen-scoring.GapExtend, x[j]-scoring.GapExtend)
+			y = max(h[j-1]-scoring.GapOpen-scoring.GapExtend, y-scoring.GapExtend)
+			score := max(diag+scoring.Matrix.Score(a[i-1], b[j-1]), x[j], y)
+			if local {
+				score = max(score, 0)
+				if score > best {
+					best, bestI, bestJ = score, i, j
+				}
+			}
+			diag, h[j] = h[j], score
+		}
+		if !local && h[m] > best {
+			best, bestI, bestJ = h[m], i, m
+		}
+	}
+	if !local {
+		for j := 0; j <= m; j++ {
+			if h[j] > best {
+				best, bestI, bestJ = h[j], n, j
+			}
+		}
+	}
+	return best, bestI, bestJ
+}
+
+// linear collects the operations of a global alignment computed by divide and conquer.
+type linear struct {
+	scoring Scoring
+	ops     []Op
+}
+
+// diff appends the operations of the best global alignment of a against b. A gap of residues of
+// a, an Insertion, at the start or end costs tb or te to open instead of GapOpen; they are 0 when
+// the gap continues one of the enclosing problem.
+func (l *linear) diff(a, b []protein.Protein, tb, te int) {
+	s := l.scoring
+	n, m := len(a), len(b)
+	switch {
+	case n == 0:
+		l.ops = appendOp(l.ops, Deletion, m)
+		return
+	case m == 0:
+		l.ops = appendOp(l.ops, Insertion, n)
+		return
+	case n == 1:
+		// a[0] either faces a gap joined to the cheaper end, or one of the residues of b
+		best, bestJ := -(min(tb, te)+s.GapExtend)+s.gap(m), -1
+		for j := 0; j < m; j++ {
+			if score := s.gap(j) + s.Matrix.Score(a[0], b[j]) + s.gap(m-j-1); score > best {
+				best, bestJ = score, j
+			}
+		}
+		switch {
+		case bestJ >= 0:
+			l.ops = appendOp(l.ops, Deletion, bestJ)
+			l.ops = appendOp(l.ops, Match, 1)
+			l.ops = appendOp(l.ops, Deletion, m-bestJ-1)
+		case tb <= te:
+			l.ops = appendOp(l.ops, Insertion, 1)
+			l.ops = appendOp(l.ops, Deletion, m)
+		default:
+			l.ops = appendOp(l.ops, Deletion, m)
+			l.ops = appendOp(l.ops, Insertion, 1)
+		}
+		return
+	}
+
+	// Split a in the middle and find where the best alignment crosses the middle row, either
+	// between two states or within a gap of a, whose two halves then share one opening
+	mid := n / 2
+	hf, xf := l.lastRow(a[:mid], b, tb)
+	hr, xr := l.lastRow(reverse(a[mid:]), reverse(b), te)
+	best, bestJ, inGap := minScore, 0, false
+	for j := 0; j <= m; j++ {
+		if score := hf[j] + hr[m-j]; score > best {
+			best, bestJ, inGap = score, j, false
+		}
+		if score := xf[j] + xr[m-j] + s.GapOpen; score > best {
+			best, bestJ, inGap = score, j, true
+		}
+	}
+	if !inGap {
+		l.diff(a[:mid], b[:bestJ], tb, s.GapOpen)
+		l.diff(a[mid:], b[bestJ:], s.GapOpen, te)
+		return
+	}
+	l.diff(a[:mid-1], b[:bestJ], tb, 0)
+	l.ops = appendOp(l.ops, Insertion, 2)
+	l.diff(a[mid+1:], b[bestJ:], 0, te)
+}
+
+// lastRow returns the last row of the global alignment matrix of a against b, with the best
+// scores ending in any state and ending in an Insertion. A gap of a at the start costs tb to open.
+func (l *linear) lastRow(a, b []protein.Protein, tb int) ([]int, []int) {
+	s := l.scoring
+	m := len(b)
+	h := make([]int, m+1)
+	x := make([]int, m+1)
+	for j := 1; j <= m; j++ {
+		h[j], x[j] = s.gap(j), minScore
+	}
+	x[0] = minScore
+	for i := 1; i <= len(a); i++ {
+		diag := h[0]
+		h[0] = -(tb + i*s.GapExtend)
+		x[0] = h[0]
+		y := minScore
+		for j := 1; j <= m; j++ {
+			x[j] = max(h[j]-s.GapOpen-s.GapExtend, x[j]-s.GapExtend)
+			y = max(h[j-1]-s.GapOpen-s.GapExtend, y-s.GapExtend)
+			score := max(diag+s.Matrix.Score(a[i-1], b[j-1]), x[j], y)
+			diag, h[j] = h[j], score
+		}
+	}
+	return h, x
+}
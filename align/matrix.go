@@ -0,0 +1,215 @@
+package align
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+	"gopher-proteinlab/protein"
+)
+
+// Alphabet lists the residues of a Matrix in the order of the NCBI matrix files. Residues outside
+// it, such as Sec, Pyl, Xle and the unusual amino acids, are scored as X.
+const Alphabet = "ARNDCQEGHILKMFPSTWYVBZX*"
+
+// Matrix is an amino acid substitution matrix. Scores are indexed by the positions of the residues
+// in Alphabet.
+type Matrix struct {
+	Name   string
+	Scores [len(Alphabet)][len(Alphabet)]int
+}
+
+// matrixIndex maps every Protein to its position in Alphabet.
+var matrixIndex [256]uint8
+
+func init() {
+	for i := range matrixIndex {
+		matrixIndex[i] = uint8(len(Alphabet) - 2) // X
+	}
+	for i := 0; i < len(Alphabet); i++ {
+		matrixIndex[Alphabet[i]] = uint8(i)
+	}
+	loadMatrices()
+}
+
+// Score returns the substitution score of the residues a and b.
+func (m *Matrix) Score(a, b protein.Protein) int {
+	return m.Scores[matrixIndex[a]][matrixIndex[b]]
+}
+
+// BLOSUM62 is the BLOSUM62 matrix of Henikoff and Henikoff (1992), the default of BLASTP.
+var BLOSUM62 = Matrix{"BLOSUM62", [len(Alphabet)][len(Alphabet)]int{
+	{4, -1, -2, -2, 0, -1, -1, 0, -2, -1, -1, -1, -1, -2, -1, 1, 0, -3, -2, 0, -2, -1, 0, -4},
+	{-1, 5, 0, -2, -3, 1, 0, -2, 0, -3, -2, 2, -1, -3, -2, -1, -1, -3, -2, -3, -1, 0, -1, -4},
+	{-2, 0, 6, 1, -3, 0, 0, 0, 1, -3, -3, 0, -2, -3, -2, 1, 0, -4, -2, -3, 3, 0, -1, -4},
+	{-2, -2, 1, 6, -3, 0, 2, -1, -1, -3, -4, -1, -3, -3, -1, 0, -1, -4, -3, -3, 4, 1, -1, -4},
+	{0, -3, -3, -3, 9, -3, -4, -3, -3, -1, -1, -3, -1, -2, -3, -1, -1, -2, -2, -1, -3, -3, -2, -4},
+	{-1, 1, 0, 0, -3, 5, 2, -2, 0, -3, -2, 1, 0, -3, -1, 0, -1, -2, -1, -2, 0, 3, -1, -4},
+	{-1, 0, 0, 2, -4, 2, 5, -2, 0, -3, -3, 1, -2, -3, -1, 0, -1, -3, -2, -2, 1, 4, -1, -4},
+	{0, -2, 0, -1, -3, -2, -2, 6, -2, -4, -4, -2, -3, -3, -2, 0, -2, -2, -3, -3, -1, -2, -1, -4},
+	{-2, 0, 1, -1, -3, 0, 0, -2, 8, -3, -3, -1, -2, -1, -2, -1, -2, -2, 2, -3, 0, 0, -1, -4},
+	{-1, -3, -3, -3, -1, -3, -3, -4, -3, 4, 2, -3, 1, 0, -3, -2, -1, -3, -1, 3, -3, -3, -1, -4},
+	{-1, -2, -3, -4, -1, -2, -3, -4, -3, 2, 4, -2, 2, 0, -3, -2, -1, -2, -1, 1, -4, -3, -1, -4},
+	{-1, 2, 0, -1, -3, 1, 1, -2, -1, -3, -2, 5, -1, -3, -1, 0, -1, -3, -2, -2, 0, 1, -1, -4},
+	{-1, -1, -2, -3, -1, 0, -2, -3, -2, 1, 2, -1, 5, 0, -2, -1, -1, -1, -1, 1, -3, -1, -1, -4},
+	{-2, -3, -3, -3, -2, -3, -3, -3, -1, 0, 0, -3, 0, 6, -4, -2, -2, 1, 3, -1, -3, -3, -1, -4},
+	{-1, -2, -2, -1, -3, -1, -1, -2, -2, -3, -3, -1, -2, -4, 7, -1, -1, -4, -3, -2, -2, -1, -2, -4},
+	{1, -1, 1, 0, -1, 0, 0, 0, -1, -2, -2, 0, -1, -2, -1, 4, 1, -3, -2, -2, 0, 0, 0, -4},
+	{0, -1, 0, -1, -1, -1, -1, -2, -2, -1, -1, -1, -1, -2, -1, 1, 5, -2, -2, 0, -1, -1, 0, -4},
+	{-3, -3, -4, -4, -2, -2, -3, -2, -2, -3, -2, -3, -1, 1, -4, -3, -2, 11, 2, -3, -4, -3, -2, -4},
+	{-2, -2, -2, -3, -2, -1, -2, -3, 2, -1, -1, -2, -1, 3, -3, -2, -2, 2, 7, -1, -3, -2, -1, -4},
+	{0, -3, -3, -3, -1, -2, -2, -3, -3, 3, 1, -2, 1, -1, -2, -2, 0, -3, -1, 4, -3, -2, -1, -4},
+	{-2, -1, 3, 4, -3, 0, 1, -1, 0, -3, -4, 0, -3, -3, -2, 0, -1, -4, -3, -3, 4, 1, -1, -4},
+	{-1, 0, 0, 1, -3, 3, 4, -2, 0, -3, -3, 1, -1, -3, -1, 0, -1, -3, -2, -2, 1, 4, -1, -4},
+	{0, -1, -1, -1, -2, -1, -1, -1, -1, -1, -1, -1, -1, -1, -2, 0, 0, -2, -1, -1, -1, -1, -1, -4},
+	{-4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, -4, 1},
+}}
+
+//go:embed matrices
+var matrixFiles embed.FS
+
+// Matrices holds the built-in substitution matrices keyed by lower case name: the BLOSUM matrices
+// of the NCBI, BLOSUM30 to BLOSUM100, and PAM30, 70 and 250. Others can be read with ReadMatrix.
+var Matrices = map[string]*Matrix{"blosum62": &BLOSUM62}
+
+// loadMatrices parses the NCBI matrix files embedded in the package into Matrices.
+func loadMatrices() {
+	entries, err := matrixFiles.ReadDir("matrices")
+	parseio.ExitOnError(err)
+	for _, entry := range entries {
+		file, err := matrixFiles.Open(path.Join("matrices", entry.Name()))
+		parseio.ExitOnError(err)
+		m, err := ParseMatrix(file, entry.Name())
+		parseio.ExitOnError(err)
+		file.Close()
+		Matrices[strings.ToLower(m.Name)] = m
+	}
+}
+
+// LookupMatrix returns the built-in substitution matrix with the given name, ignoring case.
+func LookupMatrix(name string) (*Matrix, error) {
+	if m, ok := Matrices[strings.ToLower(name)]; ok {
+		return m, nil
+	}
+	names := make([]string, 0, len(Matrices))
+	for _, m := range Matrices {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown substitution matrix %q, expected one of %v", name, names)
+}
+
+// ReadMatrix reads a substitution matrix in the NCBI text format from a file, which may be
+// gzipped. The matrix is named after the file.
+func ReadMatrix(filename string) (*Matrix, error) {
+	reader := parseio.NewCodeReader(filename)
+	defer reader.Close()
+	name := strings.TrimSuffix(path.Base(filename), ".gz")
+	m, err := ParseMatrix(reader, strings.TrimSuffix(name, path.Ext(name)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return m, nil
+}
+
+// ParseMatrix parses a substitution matrix in the NCBI text format, as distributed with BLAST:
+// comment lines starting with '#', a header line of one letter residue codes and a line per
+// residue starting with its code followed by its scores in the order of the header. Residues of
+// the header outside Alphabet are ignored. Residues of Alphabet missing from the file score as
+// X, or as the lowest score of the file when X is missing too, and * scores 1 against itself.
+func ParseMatrix(r io.Reader, name string) (*Matrix, error) {
+	scanner := bufio.NewScanner(r)
+	var columns []int // Position in Alphabet of every header column, or -1
+	var seen [len(Alphabet)][len(Alphabet)]bool
+	var rows [len(Alphabet)]bool
+	m := &Matrix{Name: name}
+	lowest := math.MaxInt
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if columns == nil {
+			for _, field := range fields {
+				if len(field) != 1 {
+					return nil, fmt.Errorf("line %d: invalid residue %q in header", line, field)
+				}
+				columns = append(columns, strings.IndexByte(Alphabet, strings.ToUpper(field)[0]))
+			}
+			continue
+		}
+
+		if len(fields[0]) != 1 {
+			return nil, fmt.Errorf("line %d: invalid residue %q", line, fields[0])
+		}
+		if len(fields)-1 != len(columns) {
+			return nil, fmt.Errorf("line %d: found %d scores, expected %d", line, len(fields)-1, len(columns))
+		}
+		i := strings.IndexByte(Alphabet, strings.ToUpper(fields[0])[0])
+		if i >= 0 && rows[i] {
+			return nil, fmt.Errorf("line %d: duplicate row %s", line, fields[0])
+		}
+		for k, field := range fields[1:] {
+			score, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid score %q", line, field)
+			}
+			lowest = min(lowest, score)
+			if j := columns[k]; i >= 0 && j >= 0 {
+				m.Scores[i][j], seen[i][j] = score, true
+			}
+		}
+		if i >= 0 {
+			rows[i] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("no matrix found")
+	}
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			if !seen[i][j] {
+				return nil, fmt.Errorf("missing score of %c against %c", Alphabet[i], Alphabet[j])
+			}
+		}
+	}
+
+	// Residues missing from the file score as X, or as the lowest score without X
+	var substitute [len(Alphabet)]int
+	x, stop := len(Alphabet)-2, len(Alphabet)-1
+	for i := range substitute {
+		switch {
+		case seen[i][i]:
+			substitute[i] = i
+		case i != stop && seen[x][x]:
+			substitute[i] = x
+		default:
+			substitute[i] = -1
+		}
+	}
+	for i := range m.Scores {
+		for j := range m.Scores[i] {
+			si, sj := substitute[i], substitute[j]
+			switch {
+			case seen[i][j]:
+			case i == stop && j == stop:
+				m.Scores[i][j] = 1
+			case si >= 0 && sj >= 0 && seen[si][sj]:
+				m.Scores[i][j] = m.Scores[si][sj]
+			default:
+				m.Scores[i][j] = lowest
+			}
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,154 @@
+package align
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+	"gopher-proteinlab/protein"
+)
+
+func TestMatrices(t *testing.T) {
+	// Ungapped lambda and relative entropy in nats with the Robinson and Robinson frequencies,
+	// as tabulated by BLAST for BLOSUM45, 50, 62, 80 and 90 and the PAM matrices. BLAST has no
+	// table for the other BLOSUM matrices, whose values were computed by this package
+	tests := []struct {
+		name            string
+		lambda, entropy float64
+	}{
+		{"BLOSUM30", 0.1382, 0.0896},
+		{"BLOSUM35", 0.1693, 0.1248},
+		{"BLOSUM40", 0.1699, 0.1804},
+		{"BLOSUM45", 0.2291, 0.2514},
+		{"BLOSUM50", 0.2318, 0.3362},
+		{"BLOSUM55", 0.2235, 0.3539},
+		{"BLOSUM60", 0.3219, 0.3816},
+		{"BLOSUM62", 0.3176, 0.4012},
+		{"BLOSUM65", 0.3159, 0.4365},
+		{"BLOSUM70", 0.3391, 0.5627},
+		{"BLOSUM75", 0.3458, 0.6224},
+		{"BLOSUM80", 0.3430, 0.6568},
+		{"BLOSUM85", 0.3403, 0.7241},
+		{"BLOSUM90", 0.3346, 0.7547},
+		{"BLOSUM100", 0.2268, 0.9536},
+		{"PAM30", 0.3400, 1.754},
+		{"PAM70", 0.3345, 1.029},
+		{"PAM250", 0.2252, 0.2223},
+	}
+	if len(Matrices) != len(tests) {
+		t.Errorf("Error: found %d built-in matrices, expected: %d", len(Matrices), len(tests))
+	}
+	for _, test := range tests {
+		m, err := LookupMatrix(strings.ToLower(test.name))
+		if err != nil {
+			t.Errorf("Error: LookupMatrix(%s) returned %v", test.name, err)
+			continue
+		}
+		if m.Name != test.name {
+			t.Errorf("Error: LookupMatrix(%s).Name = %s", test.name, m.Name)
+		}
+		for i := range m.Scores {
+			for j := range m.Scores {
+				if m.Scores[i][j] != m.Scores[j][i] {
+					t.Errorf("Error: %s is not symmetric for %c and %c", test.name, Alphabet[i], Alphabet[j])
+				}
+			}
+		}
+		if lambda := m.Lambda(BackgroundFrequencies); math.Abs(lambda-test.lambda) > 5e-5 {
+			t.Errorf("Error: %s Lambda() = %.5f, expected: %.4f", test.name, lambda, test.lambda)
+		}
+		if entropy := m.Entropy(BackgroundFrequencies) * math.Ln2; math.Abs(entropy-test.entropy) > 5e-4 {
+			t.Errorf("Error: %s Entropy() = %.5f nats, expected: %.4f", test.name, entropy, test.entropy)
+		}
+		if m.ExpectedScore(BackgroundFrequencies) >= 0 {
+			t.Errorf("Error: %s ExpectedScore() = %.4f, expected a negative score", test.name, m.ExpectedScore(BackgroundFrequencies))
+		}
+	}
+	if _, err := LookupMatrix("blosum63"); err == nil {
+		t.Errorf("Error: LookupMatrix(blosum63) expected an error")
+	}
+
+	var positive Matrix
+	for i := range positive.Scores {
+		for j := range positive.Scores[i] {
+			positive.Scores[i][j] = 1
+		}
+	}
+	if positive.Lambda(BackgroundFrequencies) != 0 || positive.Entropy(BackgroundFrequencies) != 0 {
+		t.Errorf("Error: Lambda() of a matrix with a positive expected score = %f, expected: 0", positive.Lambda(BackgroundFrequencies))
+	}
+}
+
+// formatMatrix writes the scores of BLOSUM62 for the residues in NCBI format.
+func formatMatrix(residues string) string {
+	var sb strings.Builder
+	sb.WriteString("# Test matrix\n ")
+	for _, r := range residues {
+		fmt.Fprintf(&sb, " %3c", r)
+	}
+	sb.WriteByte('\n')
+	for _, a := range residues {
+		sb.WriteRune(a)
+		for _, b := range residues {
+			fmt.Fprintf(&sb, " %3d", BLOSUM62.Score(protein.Protein(a), protein.Protein(b)))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func TestParseMatrix(t *testing.T) {
+	m, err := ParseMatrix(strings.NewReader(formatMatrix(Alphabet)), "test")
+	if err != nil || m.Scores != BLOSUM62.Scores || m.Name != "test" {
+		t.Errorf("Error: ParseMatrix() of BLOSUM62 = %v, %v", m, err)
+	}
+
+	// Without X and *, B scores like X and X as the lowest score, while * matches itself
+	if m, err = ParseMatrix(strings.NewReader(formatMatrix(Alphabet[:22])), "test"); err != nil {
+		t.Fatalf("Error: ParseMatrix() returned %v", err)
+	}
+	if m.Score('B', 'N') != 3 || m.Score('X', 'A') != -4 || m.Score('U', 'A') != -4 || m.Score('*', 'A') != -4 || m.Score('*', '*') != 1 {
+		t.Errorf("Error: ParseMatrix() filled %d, %d, %d, %d, %d", m.Score('B', 'N'), m.Score('X', 'A'), m.Score('U', 'A'), m.Score('*', 'A'), m.Score('*', '*'))
+	}
+
+	// Residues outside Alphabet are ignored and residues missing from the file score as X
+	if m, err = ParseMatrix(strings.NewReader(strings.ToLower(formatMatrix("ARNDCQEGHILKMFPSTWYVJX"))), "test"); err != nil {
+		t.Fatalf("Error: ParseMatrix() returned %v", err)
+	}
+	if m.Score('B', 'A') != 0 || m.Score('Z', 'Z') != -1 || m.Score('A', 'W') != -3 {
+		t.Errorf("Error: ParseMatrix() filled %d, %d, %d", m.Score('B', 'A'), m.Score('Z', 'Z'), m.Score('A', 'W'))
+	}
+
+	blosum62 := formatMatrix(Alphabet)
+	for _, text := range []string{
+		"",
+		formatMatrix("ARND"),
+		strings.Replace(blosum62, "A   4", "A   x", 1),
+		strings.Replace(blosum62, " -4\n", "\n", 1),
+		strings.Replace(blosum62, "\nR", "\nA", 1),
+		strings.Replace(blosum62, "\nR", "\nRR", 1),
+	} {
+		if _, err := ParseMatrix(strings.NewReader(text), "test"); err == nil {
+			t.Errorf("Error: ParseMatrix() expected an error for:\n%s", text)
+		}
+	}
+}
+
+func TestReadMatrix(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "BLOSUM62.txt")
+	parseio.ExitOnError(os.WriteFile(filename, []byte(formatMatrix(Alphabet)), 0o644))
+	m, err := ReadMatrix(filename)
+	if err != nil || m.Name != "BLOSUM62" || m.Scores != BLOSUM62.Scores {
+		t.Errorf("Error: ReadMatrix() = %v, %v", m, err)
+	}
+
+	parseio.ExitOnError(os.WriteFile(filename, []byte("A R\nA 1\n"), 0o644))
+	if _, err := ReadMatrix(filename); err == nil || !strings.HasPrefix(err.Error(), filename) {
+		t.Errorf("Error: ReadMatrix() of an invalid file returned %v", err)
+	}
+}
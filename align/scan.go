@@ -0,0 +1,214 @@
+package align
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopher-proteinlab/parseio"
+	"gopher-proteinlab/protein"
+	"gopher-proteinlab/uniprot"
+)
+
+// Subject is a database sequence searched by Scan.
+type Subject struct {
+	ID          string // FASTA identifier or UniProt accession
+	Description string
+	Sequence    []protein.Protein
+}
+
+// Hit is a database sequence whose best local alignment to the query reaches the minimum score.
+type Hit struct {
+	Subject   Subject
+	Alignment Alignment // Local alignment of the query against the subject
+}
+
+// ScanOptions sets the minimum score of the hits of Scan and the number of goroutines aligning
+// sequences.
+type ScanOptions struct {
+	MinScore int // Lowest score reported, at least 1
+	Workers  int // Goroutines aligning subjects, runtime.NumCPU() when 0
+}
+
+// scanResult is a hit with the position of its subject in the database.
+type scanResult struct {
+	index int
+	hit   Hit
+}
+
+// scanJob is a subject with its position in the database.
+type scanJob struct {
+	index   int
+	subject Subject
+}
+
+// Scan searches the subjects received from a channel for local alignments with the query. Every
+// subject is scored with the striped profile of ScoreLocal; only the subjects reaching the minimum
+// score are aligned with Align. Hits are returned by decreasing score, then in the order the
+// subjects were received. Scan returns once the channel is closed.
+func Scan(query []protein.Protein, scoring Scoring, subjects <-chan Subject, opts ScanOptions) []Hit {
+	profile := NewProfile(query, scoring)
+	minScore := max(opts.MinScore, 1)
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan scanJob, workers)
+	results := make(chan scanResult, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if profile.ScoreLocal(job.subject.Sequence) >= minScore {
+					a := Align(query, job.subject.Sequence, Local, scoring)
+					results <- scanResult{job.index, Hit{Subject: job.subject, Alignment: a}}
+				}
+			}
+		}()
+	}
+	go func() {
+		index := 0
+		for subject := range subjects {
+			jobs <- scanJob{index, subject}
+			index++
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var found []scanResult
+	for result := range results {
+		found = append(found, result)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].hit.Alignment.Score != found[j].hit.Alignment.Score {
+			return found[i].hit.Alignment.Score > found[j].hit.Alignment.Score
+		}
+		return found[i].index < found[j].index
+	})
+	hits := make([]Hit, len(found))
+	for i, result := range found {
+		hits[i] = result.hit
+	}
+	return hits
+}
+
+// ScanFile searches a UniProt FASTA or XML file, which may be gzipped, with Scan. The format is
+// recognized from the first character of the file. The file is read as the sequences are aligned;
+// when it turns out malformed, the hits found up to the error are returned with it.
+func ScanFile(query []protein.Protein, scoring Scoring, filename string, opts ScanOptions) ([]Hit, error) {
+	reader := parseio.NewCodeReader(filename)
+	defer reader.Close()
+
+	subjects := make(chan Subject, max(opts.Workers, runtime.NumCPU()))
+	var err error
+	go func() {
+		defer close(subjects)
+		err = readSubjects(reader.Reader, subjects)
+	}()
+	hits := Scan(query, scoring, subjects, opts)
+	if err != nil {
+		return hits, fmt.Errorf("%s: %v", filename, err)
+	}
+	return hits, nil
+}
+
+// readSubjects sends the sequences of a FASTA or UniProt XML file to subjects.
+func readSubjects(reader *bufio.Reader, subjects chan<- Subject) error {
+	for {
+		b, err := reader.Peek(1)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			reader.ReadByte()
+		case '>':
+			return readFasta(reader, subjects)
+		case '<':
+			return readUniProtXML(reader, subjects)
+		default:
+			return fmt.Errorf("unrecognized sequence file starting with %q", b[0])
+		}
+	}
+}
+
+// readFasta sends the records of a FASTA file to subjects. The identifier is the first word of
+// the header, e.g. sp|P0C9F0|1001R_ASFK5, and the description the rest of it. Residues may be
+// lower case; a record with invalid residues is reported with the line of the first of them.
+func readFasta(reader io.Reader, subjects chan<- Subject) error {
+	scanner := bufio.NewScanner(reader)
+	var subject *Subject
+	var sequence strings.Builder
+	var offsets, lines []int // Sequence offset and file line of each sequence line of the record
+	send := func() error {
+		if subject != nil {
+			residues, err := protein.Encode(sequence.String(), protein.EncodeOptions{FoldCase: true})
+			if invalid, ok := err.(*protein.InvalidResidueError); ok {
+				i := sort.SearchInts(offsets, invalid.Positions[0]) - 1
+				return fmt.Errorf("line %d: record %s: %v", lines[i], subject.ID, err)
+			}
+			subject.Sequence = residues
+			subjects <- *subject
+		}
+		sequence.Reset()
+		offsets, lines = offsets[:0], lines[:0]
+		return nil
+	}
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(text, ">"):
+			if err := send(); err != nil {
+				return err
+			}
+			id, description, _ := strings.Cut(text[1:], " ")
+			if id == "" {
+				return fmt.Errorf("line %d: FASTA header without an identifier", line)
+			}
+			subject = &Subject{ID: id, Description: strings.TrimSpace(description)}
+		case subject == nil && text != "":
+			return fmt.Errorf("line %d: sequence before the first FASTA header", line)
+		case text != "":
+			offsets, lines = append(offsets, sequence.Len()), append(lines, line)
+			sequence.WriteString(text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return send()
+}
+
+// readUniProtXML sends the entries of a UniProt XML file to subjects, identified by their
+// primary accession. An entry with invalid residues is reported with the line where it ends.
+func readUniProtXML(reader io.Reader, subjects chan<- Subject) error {
+	decoder := xml.NewDecoder(reader)
+	for {
+		entry, err := uniprot.ParseUniProt(decoder)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		sequence, err := entry.Sequence.ParseProteins()
+		if err != nil {
+			line, _ := decoder.InputPos()
+			return fmt.Errorf("line %d: entry %s: %v", line, entry.Accession, err)
+		}
+		subjects <- Subject{ID: entry.Accession, Description: entry.RecordDescription(), Sequence: sequence}
+	}
+}
@@ -0,0 +1,77 @@
+package align
+
+import "math"
+
+// BackgroundFrequencies holds the amino acid frequencies of Robinson and Robinson (1991), used by
+// BLAST, in the order of the first 20 residues of Alphabet.
+var BackgroundFrequencies = [20]float64{
+	0.07805, 0.05129, 0.04487, 0.05364, 0.01925, 0.04264, 0.06295, 0.07377, 0.02199, 0.05142,
+	0.09019, 0.05744, 0.02243, 0.03856, 0.05203, 0.07120, 0.05841, 0.01330, 0.03216, 0.06441,
+}
+
+// ExpectedScore returns the mean score of a pair of the 20 standard amino acids drawn at random
+// with the given frequencies. Local alignment statistics require it to be negative.
+func (m *Matrix) ExpectedScore(freqs [20]float64) float64 {
+	var expected float64
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			expected += freqs[i] * freqs[j] * float64(m.Scores[i][j])
+		}
+	}
+	return expected
+}
+
+// Lambda returns the scale of the matrix, the positive lambda of Karlin and Altschul (1990) in
+// nats per score unit, for which the sum of p(i) p(j) exp(lambda s(i,j)) over all pairs is 1. It
+// returns 0 when the expected score is not negative or no score is positive, as there is no
+// such lambda.
+func (m *Matrix) Lambda(freqs [20]float64) float64 {
+	positive := false
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			positive = positive || m.Scores[i][j] > 0 && freqs[i] > 0 && freqs[j] > 0
+		}
+	}
+	if !positive || m.ExpectedScore(freqs) >= 0 {
+		return 0
+	}
+
+	// The sum is convex in lambda, below 1 between 0 and the root and above it past the root
+	sum := func(lambda float64) float64 {
+		var s float64
+		for i := 0; i < 20; i++ {
+			for j := 0; j < 20; j++ {
+				s += freqs[i] * freqs[j] * math.Exp(lambda*float64(m.Scores[i][j]))
+			}
+		}
+		return s
+	}
+	low, high := 0.0, 0.5
+	for sum(high) < 1 {
+		low, high = high, 2*high
+	}
+	for k := 0; k < 100; k++ {
+		mid := (low + high) / 2
+		if sum(mid) < 1 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+// Entropy returns the relative entropy of the matrix in bits per aligned pair: the information
+// per position of the target frequencies p(i) p(j) exp(lambda s(i,j)) implied by the scores,
+// relative to the background frequencies. It is 0 when Lambda is.
+func (m *Matrix) Entropy(freqs [20]float64) float64 {
+	lambda := m.Lambda(freqs)
+	var entropy float64
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			score := lambda * float64(m.Scores[i][j])
+			entropy += freqs[i] * freqs[j] * math.Exp(score) * score
+		}
+	}
+	return entropy / math.Ln2
+}
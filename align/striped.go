@@ -0,0 +1,199 @@
+package align
+
+import "gopher-proteinlab/protein"
+
+// lanes describes unsigned integer lanes packed into a uint64, which are operated on all at once
+// with ordinary integer instructions (SIMD within a register). The highest bit of every lane is a
+// guard bit that keeps carries and borrows from crossing into the next lane, so lanes hold values
+// of up to max.
+type lanes struct {
+	count int    // Lanes per vector
+	width uint   // Bits per lane including the guard bit
+	max   uint64 // Largest value of a lane
+	ones  uint64 // 1 in every lane
+	high  uint64 // The guard bit of every lane
+}
+
+var (
+	byteLanes = newLanes(8)
+	wordLanes = newLanes(16)
+)
+
+func newLanes(width uint) lanes {
+	l := lanes{count: 64 / int(width), width: width, max: 1<<(width-1) - 1}
+	for k := 0; k < l.count; k++ {
+		l.ones |= 1 << (uint(k) * width)
+	}
+	l.high = l.ones << (width - 1)
+	return l
+}
+
+// splat returns a vector with x in every lane.
+func (l lanes) splat(x int) uint64 {
+	return uint64(x) * l.ones
+}
+
+// mask turns the guard bits of v into masks of the value bits of their lanes.
+func (l lanes) mask(v uint64) uint64 {
+	v &= l.high
+	return v - v>>(l.width-1)
+}
+
+// adds adds the lanes of a and b, saturating at max.
+func (l lanes) adds(a, b uint64) uint64 {
+	s := a + b
+	return (s | l.mask(s)) &^ l.high
+}
+
+// subs subtracts the lanes of b from a, saturating at 0.
+func (l lanes) subs(a, b uint64) uint64 {
+	d := (a | l.high) - b
+	return d & l.mask(d)
+}
+
+// maxs returns the larger of each pair of lanes of a and b.
+func (l lanes) maxs(a, b uint64) uint64 {
+	m := l.mask((a | l.high) - b)
+	return a&m | b&^m
+}
+
+// shift moves every lane up by one, the lane of the first segment receiving 0.
+func (l lanes) shift(v uint64) uint64 {
+	return v << l.width
+}
+
+// hmax returns the largest lane of v.
+func (l lanes) hmax(v uint64) int {
+	var best uint64
+	for k := 0; k < l.count; k++ {
+		best = max(best, v>>(uint(k)*l.width)&l.max)
+	}
+	return int(best)
+}
+
+// stripedProfile holds the biased scores of the query against every residue of Alphabet in the
+// striped layout of Farrar (2007): lane k of vector j holds query position k*segments + j, so
+// that the dependencies along the query only cross lanes once per column.
+type stripedProfile struct {
+	lanes    lanes
+	segments int
+	vectors  []uint64 // segments vectors per residue of Alphabet
+}
+
+// Profile is a query prepared for fast local alignment scores against many targets, as in a
+// database scan. It is read-only once built and can be shared by goroutines.
+type Profile struct {
+	query   []protein.Protein
+	scoring Scoring
+	bias    int // Added to every substitution score to make it non-negative
+	striped []stripedProfile
+}
+
+// NewProfile prepares query for ScoreLocal with the given scoring. Scores are computed with 8
+// bit lanes first, which hold scores up to about 120 with BLOSUM62, then with 16 bit lanes and
+// finally without lanes when they overflow, skipping lanes too narrow for the matrix and gap
+// penalties.
+func NewProfile(query []protein.Protein, scoring Scoring) *Profile {
+	p := &Profile{query: query, scoring: scoring}
+	lowest, highest := 0, 0
+	for i := range scoring.Matrix.Scores {
+		for _, score := range scoring.Matrix.Scores[i] {
+			lowest, highest = min(lowest, score), max(highest, score)
+		}
+	}
+	p.bias = -lowest
+	for _, l := range []lanes{byteLanes, wordLanes} {
+		if uint64(p.bias+highest) >= l.max || uint64(scoring.GapOpen+scoring.GapExtend) >= l.max {
+			continue
+		}
+		s := stripedProfile{lanes: l, segments: (len(query) + l.count - 1) / l.count}
+		s.vectors = make([]uint64, len(Alphabet)*s.segments)
+		for a := 0; a < len(Alphabet); a++ {
+			row := s.vectors[a*s.segments:]
+			for j := 0; j < s.segments; j++ {
+				for k := 0; k < l.count; k++ {
+					// Positions past the end of the query score 0 after the bias is removed
+					if i := k*s.segments + j; i < len(query) {
+						score := scoring.Matrix.Scores[a][matrixIndex[query[i]]] + p.bias
+						row[j] |= uint64(score) << (uint(k) * l.width)
+					}
+				}
+			}
+		}
+		p.striped = append(p.striped, s)
+	}
+	return p
+}
+
+// ScoreLocal returns the score of the best local alignment of the query against target, the
+// score of Align in Local mode, without computing the alignment itself.
+func (p *Profile) ScoreLocal(target []protein.Protein) int {
+	if len(p.query) == 0 || len(target) == 0 {
+		return 0
+	}
+	for _, s := range p.striped {
+		if score, ok := p.scoreStriped(&s, target); ok {
+			return score
+		}
+	}
+	score, _, _ := bestCell(p.query, target, p.scoring, true, false)
+	return score
+}
+
+// scoreStriped computes the best local alignment score with the algorithm of Farrar (2007) in
+// the formulation of SSW (Zhao et al. 2013). Scores saturate at the top of the lanes, in which
+// case it returns false.
+func (p *Profile) scoreStriped(s *stripedProfile, target []protein.Protein) (int, bool) {
+	l, n := s.lanes, s.segments
+	bias := l.splat(p.bias)
+	gapOE := l.splat(p.scoring.GapOpen + p.scoring.GapExtend)
+	gapE := l.splat(p.scoring.GapExtend)
+
+	buffer := make([]uint64, 3*n)
+	hLoad, hStore, e := buffer[:n], buffer[n:2*n], buffer[2*n:]
+	var best uint64
+	for _, t := range target {
+		scores := s.vectors[int(matrixIndex[t])*n:]
+
+		// The cell of the previous column diagonal to the first segment is in the last one
+		var f, column uint64
+		h := l.shift(hStore[n-1])
+		hLoad, hStore = hStore, hLoad
+		for j := 0; j < n; j++ {
+			h = l.subs(l.adds(h, scores[j]), bias)
+			h = l.maxs(h, e[j])
+			h = l.maxs(h, f)
+			column = l.maxs(column, h)
+			hStore[j] = h
+			h = l.subs(h, gapOE)
+			e[j] = l.maxs(l.subs(e[j], gapE), h)
+			f = l.maxs(l.subs(f, gapE), h)
+			h = hLoad[j]
+		}
+
+		// Carry the gaps along the query from the last segment over to the next lanes until they
+		// no longer improve on opening a gap
+	lazyF:
+		for k := 0; k < l.count; k++ {
+			f = l.shift(f)
+			for j := 0; j < n; j++ {
+				h = l.maxs(hStore[j], f)
+				hStore[j] = h
+				column = l.maxs(column, h)
+				h = l.subs(h, gapOE)
+				e[j] = l.maxs(e[j], h)
+				f = l.subs(f, gapE)
+				if l.subs(f, h) == 0 {
+					break lazyF
+				}
+			}
+		}
+		best = l.maxs(best, column)
+	}
+
+	score := l.hmax(best)
+	if uint64(score+p.bias) >= l.max {
+		return 0, false
+	}
+	return score, true
+}
@@ -0,0 +1,180 @@
+package align
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+	"gopher-proteinlab/protein"
+)
+
+func TestLanes(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, l := range []lanes{byteLanes, wordLanes} {
+		for k := 0; k < 1000; k++ {
+			var a, b uint64
+			for lane := 0; lane < l.count; lane++ {
+				a = a<<l.width | r.Uint64()%(l.max+1)
+				b = b<<l.width | r.Uint64()%(l.max+1)
+			}
+			adds, subs, maxs := l.adds(a, b), l.subs(a, b), l.maxs(a, b)
+			for lane := 0; lane < l.count; lane++ {
+				shift := uint(lane) * l.width
+				x, y := a>>shift&l.max, b>>shift&l.max
+				if got := adds >> shift & l.max; got != min(x+y, l.max) {
+					t.Fatalf("Error: %d bit adds(%d, %d) = %d", l.width, x, y, got)
+				}
+				if got := subs >> shift & l.max; got != x-min(x, y) {
+					t.Fatalf("Error: %d bit subs(%d, %d) = %d", l.width, x, y, got)
+				}
+				if got := maxs >> shift & l.max; got != max(x, y) {
+					t.Fatalf("Error: %d bit maxs(%d, %d) = %d", l.width, x, y, got)
+				}
+			}
+		}
+	}
+}
+
+func TestScoreLocal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	scorings := []Scoring{DefaultScoring, {Matrix: Matrices["pam30"], GapOpen: 9, GapExtend: 1}, {Matrix: &BLOSUM62, GapOpen: 0, GapExtend: 4}, {Matrix: Matrices["blosum45"], GapOpen: 15, GapExtend: 2}}
+	for k := 0; k < 500; k++ {
+		query := randomProtein(r, r.Intn(60))
+		target := randomProtein(r, r.Intn(200))
+		if k%2 == 0 && len(query) > 0 {
+			// Related sequences give alignments with gaps
+			target = append(append(append([]protein.Protein(nil), target[:len(target)/2]...), query[:len(query)/2]...), query[len(query)/2+1:]...)
+		}
+		scoring := scorings[k%len(scorings)]
+		if score, expected := NewProfile(query, scoring).ScoreLocal(target), Align(query, target, Local, scoring).Score; score != expected {
+			t.Fatalf("Error: ScoreLocal(%s, %s) = %d, expected: %d", protein.ToString(query), protein.ToString(target), score, expected)
+		}
+	}
+
+	// Scores that overflow 8 and 16 bit lanes
+	query := randomProtein(r, 50)
+	if score, expected := NewProfile(query, DefaultScoring).ScoreLocal(query), Align(query, query, Local, DefaultScoring).Score; score != expected || score < 256 {
+		t.Errorf("Error: ScoreLocal() of %s = %d, expected: %d", protein.ToString(query), score, expected)
+	}
+	query = protein.ToProteins(strings.Repeat("W", 6000))
+	if score := NewProfile(query, DefaultScoring).ScoreLocal(query); score != 66000 {
+		t.Errorf("Error: ScoreLocal() of 6000 W = %d, expected: 66000", score)
+	}
+}
+
+func TestScan(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	query := randomProtein(r, 80)
+	var database []Subject
+	for k := 0; k < 200; k++ {
+		seq := randomProtein(r, 50+r.Intn(300))
+		if k%10 == 0 {
+			// Plant a diverged copy of part of the query
+			start := r.Intn(40)
+			part := append([]protein.Protein(nil), query[start:start+20+r.Intn(20)]...)
+			for i := range part {
+				if r.Intn(5) == 0 {
+					part[i] = randomProtein(r, 1)[0]
+				}
+			}
+			at := r.Intn(len(seq))
+			seq = append(append(append([]protein.Protein(nil), seq[:at]...), part...), seq[at:]...)
+		}
+		database = append(database, Subject{ID: fmt.Sprintf("seq%d", k), Sequence: seq})
+	}
+
+	subjects := make(chan Subject)
+	go func() {
+		for _, subject := range database {
+			subjects <- subject
+		}
+		close(subjects)
+	}()
+	hits := Scan(query, DefaultScoring, subjects, ScanOptions{MinScore: 40, Workers: 4})
+
+	var expected []string
+	for _, subject := range database {
+		if Align(query, subject.Sequence, Local, DefaultScoring).Score >= 40 {
+			expected = append(expected, subject.ID)
+		}
+	}
+	if len(hits) != len(expected) || len(hits) < 10 {
+		t.Fatalf("Error: Scan() found %d hits, expected: %d", len(hits), len(expected))
+	}
+	for i, hit := range hits {
+		if i > 0 && hit.Alignment.Score > hits[i-1].Alignment.Score {
+			t.Errorf("Error: Scan() hits are not sorted by score")
+		}
+		if a := Align(query, hit.Subject.Sequence, Local, DefaultScoring); !reflect.DeepEqual(hit.Alignment, a) {
+			t.Errorf("Error: Scan() alignment of %s = %v, expected: %v", hit.Subject.ID, hit.Alignment, a)
+		}
+	}
+}
+
+func TestScanFile(t *testing.T) {
+	// The first 62 residues of P0C9F0 (1001R_ASFK5)
+	query := protein.ToProteins("MVRLFYNPIKYLFYRRSCKKRLRKALKKLNFYHPPKECCQIYRLLENAPGGTYFITENMTNE")
+	hits, err := ScanFile(query, DefaultScoring, "../uniprot/testdata/uniprot.xml.gz", ScanOptions{MinScore: 50})
+	if err != nil || len(hits) != 1 || hits[0].Subject.ID != "P0C9F0" || hits[0].Subject.Description != "Protein MGF 100-1R" {
+		t.Fatalf("Error: ScanFile() of UniProt XML = %v, %v", hits, err)
+	}
+	if a := hits[0].Alignment; a.TargetStart != 0 || a.Identities != len(query) {
+		t.Errorf("Error: ScanFile() alignment %d-%d with %d identities", a.TargetStart, a.TargetEnd, a.Identities)
+	}
+
+	fasta := ">sp|P0C9F0|1001R_ASFK5 Protein MGF 100-1R OS=African swine fever virus\n" +
+		"MVRLFYNPIKYLFYRRSCKKRLRKALKKLNFYHPPKECCQIYRLLENAPGGTYFITENMT\nNE\n" +
+		">unrelated\nWWWWWWWWWWWWWWWW\n\n" +
+		">half\nAAAAAAAAAAMVRLFYNPIKYLFYRRSCKKRLRKALKKLNF\n"
+	if tmpfile, err := os.CreateTemp("", "*.fasta"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.WriteString(fasta)
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+		hits, err := ScanFile(query, DefaultScoring, tmpfile.Name(), ScanOptions{MinScore: 50, Workers: 2})
+		if err != nil || len(hits) != 2 || hits[0].Subject.ID != "sp|P0C9F0|1001R_ASFK5" || hits[1].Subject.ID != "half" {
+			t.Fatalf("Error: ScanFile() of FASTA = %v, %v", hits, err)
+		}
+		if hits[0].Subject.Description != "Protein MGF 100-1R OS=African swine fever virus" || hits[1].Alignment.TargetStart != 10 {
+			t.Errorf("Error: ScanFile() hits %q, %d", hits[0].Subject.Description, hits[1].Alignment.TargetStart)
+		}
+	}
+
+	// Lower case residues are read as upper case, and invalid ones are reported with their record
+	// and line
+	for text, expected := range map[string]string{
+		">seq\nmvrlfynpikylfyrrsckkrlrkalkklnfyhppkeccqiyrllenapggtyfitenmtne\n":                                   "",
+		">seq\nMVRLFY\n\n>bad\nMVRLFY\nMVR1FY\n":                                                                   "line 6: record bad: 1 invalid amino acid symbols: '1' at 10",
+		"<uniprot><entry><accession>P0C9F0</accession><sequence>\nMVRLFY\nMVR LFY-\n</sequence></entry></uniprot>": "line 4: entry P0C9F0: 1 invalid amino acid symbols: '-' at 13",
+	} {
+		if tmpfile, err := os.CreateTemp("", "*.fasta"); parseio.ExitOnError(err) {
+			defer os.Remove(tmpfile.Name())
+			_, err = tmpfile.WriteString(text)
+			parseio.ExitOnError(err)
+			parseio.ExitOnError(tmpfile.Close())
+			hits, err := ScanFile(query, DefaultScoring, tmpfile.Name(), ScanOptions{MinScore: 50})
+			if expected == "" && (err != nil || len(hits) != 1 || hits[0].Alignment.Identities != len(query)) {
+				t.Errorf("Error: ScanFile() of lower case residues = %v, %v", hits, err)
+			}
+			if expected != "" && (err == nil || err.Error() != tmpfile.Name()+": "+expected) {
+				t.Errorf("Error: ScanFile() = %v, expected: %s", err, expected)
+			}
+		}
+	}
+
+	for _, text := range []string{"MVRLFY\n>seq\nMVRLFY\n", "> seq\nMVRLFY\n", "ID   P0C9F0\n"} {
+		if tmpfile, err := os.CreateTemp("", "*.fasta"); parseio.ExitOnError(err) {
+			defer os.Remove(tmpfile.Name())
+			_, err = tmpfile.WriteString(text)
+			parseio.ExitOnError(err)
+			parseio.ExitOnError(tmpfile.Close())
+			if _, err := ScanFile(query, DefaultScoring, tmpfile.Name(), ScanOptions{}); err == nil {
+				t.Errorf("Error: ScanFile() expected an error for %q", text)
+			}
+		}
+	}
+}
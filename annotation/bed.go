@@ -0,0 +1,368 @@
+package annotation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+)
+
+// BED is one interval of a BED file in 0-based, half-open coordinates. BlockSizes and BlockStarts
+// hold the BED12 blocks of a spliced feature, with starts relative to Start.
+type BED struct {
+	Chrom       string
+	Start       int
+	End         int
+	Name        string
+	Score       int
+	Strand      byte // '+', '-' or '.'
+	ThickStart  int
+	ThickEnd    int
+	ItemRgb     string
+	BlockSizes  []int
+	BlockStarts []int
+}
+
+// BEDMatch pairs a BED interval with the record it lies on and the features of the record that
+// overlap it. Record is nil when no record matches the chromosome of the interval.
+type BEDMatch struct {
+	BED      BED
+	Record   Record
+	Features []RecordFeature
+}
+
+// BEDReader streams the intervals of a plain or gzipped BED file one line at a time.
+type BEDReader struct {
+	filename string
+	scanner  *parseio.Scanalyzer
+}
+
+// BEDFeatures converts the features of a record to BED intervals named after their /gene,
+// /locus_tag or /product, or their key. Features with several spans, such as join locations,
+// become BED12 blocks and CDS features set the thick region. When keys are given only features
+// with one of those keys are converted, e.g. domain and region for UniProt entries, whose
+// intervals stay in protein coordinates without a strand.
+func BEDFeatures(record Record, keys ...string) ([]BED, error) {
+	features, err := record.RecordFeatures()
+	if err != nil {
+		return nil, err
+	}
+	chrom := record.RecordID()
+	if accessions := record.RecordAccessions(); len(accessions) > 0 {
+		chrom = accessions[0]
+	}
+	protein := isProteinRecord(record)
+
+	var beds []BED
+	for _, feature := range features {
+		if len(keys) > 0 && !containsKey(keys, feature.Key) {
+			continue
+		}
+		var spans []Span
+		for _, span := range feature.Location.Spans {
+			if span.Accession == "" {
+				spans = append(spans, span)
+			}
+		}
+		if len(spans) == 0 {
+			continue
+		}
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+
+		bed := BED{Chrom: chrom, Name: feature.Key, Strand: '+', ItemRgb: "0"}
+		if name := firstQualifier(feature.Qualifiers, "/gene", "/locus_tag", "/product"); name != "" {
+			bed.Name = name
+		}
+		switch strand := (Location{Spans: spans}).Strand(); {
+		case protein || strand == 0:
+			bed.Strand = '.'
+		case strand < 0:
+			bed.Strand = '-'
+		}
+		for _, span := range spans {
+			start, end := span.Start-1, span.End
+			if span.Between {
+				start = span.Start
+				end = start
+			}
+			if len(bed.BlockStarts) == 0 {
+				bed.Start = start
+			}
+			bed.BlockStarts = append(bed.BlockStarts, start-bed.Start)
+			bed.BlockSizes = append(bed.BlockSizes, end-start)
+			if end > bed.End {
+				bed.End = end
+			}
+		}
+		if len(spans) == 1 {
+			bed.BlockStarts, bed.BlockSizes = nil, nil
+		}
+		bed.ThickStart, bed.ThickEnd = bed.Start, bed.Start
+		if feature.Key == "CDS" {
+			bed.ThickEnd = bed.End
+		}
+		beds = append(beds, bed)
+	}
+	return beds, nil
+}
+
+// containsKey reports whether key is one of keys.
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ToString formats the interval as a BED line without the trailing newline, using BED12 when
+// columns is 12 and BED6 otherwise. An interval without blocks is written as a single BED12 block.
+func (b BED) ToString(columns int) string {
+	strand := b.Strand
+	if strand == 0 {
+		strand = '.'
+	}
+	line := fmt.Sprintf("%s\t%d\t%d\t%s\t%d\t%c", b.Chrom, b.Start, b.End, b.Name, b.Score, strand)
+	if columns != 12 {
+		return line
+	}
+	sizes, starts := b.BlockSizes, b.BlockStarts
+	if len(sizes) == 0 {
+		sizes, starts = []int{b.End - b.Start}, []int{0}
+	}
+	rgb := b.ItemRgb
+	if rgb == "" {
+		rgb = "0"
+	}
+	return fmt.Sprintf("%s\t%d\t%d\t%s\t%d\t%s,\t%s,", line, b.ThickStart, b.ThickEnd, rgb, len(sizes), joinInts(sizes), joinInts(starts))
+}
+
+// joinInts joins integers with commas.
+func joinInts(values []int) string {
+	text := make([]string, len(values))
+	for i, v := range values {
+		text[i] = strconv.Itoa(v)
+	}
+	return strings.Join(text, ",")
+}
+
+// WriteBED writes intervals as BED12 lines when any of them has blocks and as BED6 lines otherwise,
+// so that every line of the file has the same number of columns.
+func WriteBED(w io.Writer, beds []BED) error {
+	columns := 6
+	for _, bed := range beds {
+		if len(bed.BlockSizes) > 0 {
+			columns = 12
+			break
+		}
+	}
+	txt := parseio.NewTxtBuilder()
+	for _, bed := range beds {
+		txt.WriteString(bed.ToString(columns))
+		txt.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, txt.String())
+	return err
+}
+
+// WriteBEDFile writes intervals to a BED file, gzipping it when filename ends with .gz.
+func WriteBEDFile(filename string, beds []BED) error {
+	writer := parseio.NewWriter(filename)
+	defer writer.Close()
+	if err := WriteBED(writer, beds); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// NewBEDReader opens a BED file for reading.
+func NewBEDReader(filename string) *BEDReader {
+	return &BEDReader{
+		filename: filename,
+		scanner:  parseio.NewScanner(filename),
+	}
+}
+
+// Read returns the next interval of the file, skipping comments and track and browser lines,
+// or io.EOF once every interval has been read. Parsing errors report the file name and line number.
+func (r *BEDReader) Read() (*BED, error) {
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") ||
+			strings.HasPrefix(line, "track") || strings.HasPrefix(line, "browser") {
+			continue
+		}
+		bed, err := ParseBEDLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", r.filename, r.scanner.Line(), err)
+		}
+		return &bed, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", r.filename, r.scanner.Line()+1, err)
+	}
+	return nil, io.EOF
+}
+
+// Close closes the underlying file.
+func (r *BEDReader) Close() error {
+	return r.scanner.Close()
+}
+
+// ParseBEDLine parses a BED line with 3 to 12 tab separated columns. A BED12 line with a single
+// block spanning the interval is read without blocks.
+func ParseBEDLine(line string) (BED, error) {
+	columns := strings.Split(line, "\t")
+	if len(columns) < 3 || len(columns) > 12 {
+		return BED{}, fmt.Errorf("expected 3 to 12 tab separated columns, found %d", len(columns))
+	}
+	bed := BED{Chrom: columns[0], Strand: '.'}
+	var err error
+	if bed.Start, err = strconv.Atoi(columns[1]); err != nil {
+		return bed, fmt.Errorf("invalid start %q", columns[1])
+	}
+	if bed.End, err = strconv.Atoi(columns[2]); err != nil {
+		return bed, fmt.Errorf("invalid end %q", columns[2])
+	}
+	if bed.End < bed.Start {
+		return bed, fmt.Errorf("interval %d-%d ends before it starts", bed.Start, bed.End)
+	}
+	bed.ThickStart, bed.ThickEnd = bed.Start, bed.End
+	if len(columns) > 3 {
+		bed.Name = columns[3]
+	}
+	if len(columns) > 4 {
+		if bed.Score, err = strconv.Atoi(columns[4]); err != nil {
+			return bed, fmt.Errorf("invalid score %q", columns[4])
+		}
+	}
+	if len(columns) > 5 {
+		if len(columns[5]) != 1 || strings.IndexByte("+-.", columns[5][0]) < 0 {
+			return bed, fmt.Errorf("invalid strand %q", columns[5])
+		}
+		bed.Strand = columns[5][0]
+	}
+	if len(columns) > 7 {
+		if bed.ThickStart, err = strconv.Atoi(columns[6]); err != nil {
+			return bed, fmt.Errorf("invalid thickStart %q", columns[6])
+		}
+		if bed.ThickEnd, err = strconv.Atoi(columns[7]); err != nil {
+			return bed, fmt.Errorf("invalid thickEnd %q", columns[7])
+		}
+	}
+	if len(columns) > 8 {
+		bed.ItemRgb = columns[8]
+	}
+	if len(columns) == 12 {
+		count, err := strconv.Atoi(columns[9])
+		if err != nil {
+			return bed, fmt.Errorf("invalid blockCount %q", columns[9])
+		}
+		if bed.BlockSizes, err = splitInts(columns[10]); err != nil || len(bed.BlockSizes) != count {
+			return bed, fmt.Errorf("invalid blockSizes %q", columns[10])
+		}
+		if bed.BlockStarts, err = splitInts(columns[11]); err != nil || len(bed.BlockStarts) != count {
+			return bed, fmt.Errorf("invalid blockStarts %q", columns[11])
+		}
+		if count == 1 && bed.BlockStarts[0] == 0 && bed.BlockSizes[0] == bed.End-bed.Start {
+			bed.BlockSizes, bed.BlockStarts = nil, nil
+		}
+	}
+	return bed, nil
+}
+
+// splitInts parses a comma separated list of integers, allowing a trailing comma.
+func splitInts(text string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(strings.TrimSuffix(text, ","), ",") {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Location converts the interval to a 1-based feature location, joining its blocks and reading
+// them from the complement strand when the strand is -.
+func (b BED) Location() Location {
+	sizes, starts := b.BlockSizes, b.BlockStarts
+	if len(sizes) == 0 {
+		sizes, starts = []int{b.End - b.Start}, []int{0}
+	}
+	var loc Location
+	for i := range sizes {
+		start := b.Start + starts[i]
+		span := Span{Start: start + 1, End: start + sizes[i], Complement: b.Strand == '-'}
+		if sizes[i] == 0 {
+			span = Span{Start: start, End: start + 1, Between: true, Complement: b.Strand == '-'}
+		}
+		loc.Spans = append(loc.Spans, span)
+	}
+	if len(loc.Spans) > 1 {
+		loc.Operator = "join"
+	}
+	if b.Strand == '-' {
+		for i, j := 0, len(loc.Spans)-1; i < j; i, j = i+1, j-1 {
+			loc.Spans[i], loc.Spans[j] = loc.Spans[j], loc.Spans[i]
+		}
+	}
+	return loc
+}
+
+// AssociateBED matches intervals to records by comparing the chromosome with the accessions and
+// identifier of each record, ignoring a version suffix, and collects the features overlapping
+// each interval.
+func AssociateBED(beds []BED, records []Record) ([]BEDMatch, error) {
+	byName := make(map[string]Record)
+	for _, record := range records {
+		byName[record.RecordID()] = record
+		for _, accession := range record.RecordAccessions() {
+			byName[accession] = record
+		}
+	}
+
+	features := make(map[Record][]RecordFeature)
+	matches := make([]BEDMatch, 0, len(beds))
+	for _, bed := range beds {
+		match := BEDMatch{BED: bed}
+		record, ok := byName[bed.Chrom]
+		if !ok {
+			if i := strings.LastIndex(bed.Chrom, "."); i > 0 {
+				record, ok = byName[bed.Chrom[:i]]
+			}
+		}
+		if ok {
+			match.Record = record
+			if _, parsed := features[record]; !parsed {
+				recordFeatures, err := record.RecordFeatures()
+				if err != nil {
+					return nil, err
+				}
+				features[record] = recordFeatures
+			}
+			for _, feature := range features[record] {
+				if overlapsBED(feature.Location, bed) {
+					match.Features = append(match.Features, feature)
+				}
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+// overlapsBED reports whether any local span of a location overlaps the interval.
+func overlapsBED(loc Location, bed BED) bool {
+	for _, span := range loc.Spans {
+		if span.Accession == "" && span.Start-1 < bed.End && span.End > bed.Start {
+			return true
+		}
+	}
+	return false
+}
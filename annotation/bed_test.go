@@ -0,0 +1,86 @@
+package annotation
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+func TestBEDFeatures(t *testing.T) {
+	entry := &GenBankEntry{
+		Locus:     "TEST",
+		Accession: []string{"AB000001"},
+		Sequence:  strings.Repeat("acgt", 50),
+		Features: []Feature{
+			{Key: "gene", Location: "complement(10..150)", Qualifiers: map[string]string{"/gene": "tst"}},
+			{Key: "CDS", Location: "complement(join(20..50,100..140))", Qualifiers: map[string]string{"/gene": "tst"}},
+			{Key: "misc_feature", Location: "60^61", Qualifiers: map[string]string{"/note": "site"}},
+		},
+	}
+	beds, err := BEDFeatures(entry)
+	if err != nil {
+		t.Fatalf("BEDFeatures failed: %v", err)
+	}
+	var text strings.Builder
+	if err = WriteBED(&text, beds); err != nil {
+		t.Fatalf("WriteBED failed: %v", err)
+	}
+	expected := "AB000001\t9\t150\ttst\t0\t-\t9\t9\t0\t1\t141,\t0,\n" +
+		"AB000001\t19\t140\ttst\t0\t-\t19\t140\t0\t2\t31,41,\t0,80,\n" +
+		"AB000001\t60\t60\tmisc_feature\t0\t+\t60\t60\t0\t1\t0,\t0,\n"
+	if text.String() != expected {
+		t.Errorf("Error: WriteBED() =\n%s\nexpected:\n%s", text.String(), expected)
+	}
+
+	if genes, _ := BEDFeatures(entry, "gene"); len(genes) != 1 || genes[0].ToString(12) != strings.Split(expected, "\n")[0] {
+		t.Errorf("Error: BEDFeatures() with keys = %+v", genes)
+	}
+
+	// Read the intervals back and recover the feature locations
+	if tmpfile, err := os.CreateTemp("", "*.bed"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.WriteString("track name=test\n# comment\n" + expected)
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+
+		reader := NewBEDReader(tmpfile.Name())
+		defer reader.Close()
+		for i, feature := range entry.Features {
+			bed, err := reader.Read()
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if !reflect.DeepEqual(*bed, beds[i]) {
+				t.Errorf("Error: Read() = %+v, expected: %+v", *bed, beds[i])
+			}
+			if loc, _ := feature.ParseLocation(); !reflect.DeepEqual(bed.Location(), loc) {
+				t.Errorf("Error: Location() = %+v, expected: %+v", bed.Location(), loc)
+			}
+		}
+	}
+
+	matches, err := AssociateBED([]BED{{Chrom: "AB000001.1", Start: 55, End: 105}, {Chrom: "chr1", Start: 0, End: 10}}, []Record{entry})
+	if err != nil || len(matches) != 2 || matches[0].Record != entry || len(matches[0].Features) != 3 || matches[1].Record != nil {
+		t.Errorf("Error: AssociateBED() = %+v, %v", matches, err)
+	}
+}
+
+func TestParseBEDLine(t *testing.T) {
+	bed, err := ParseBEDLine("chr1\t100\t200\tpeak1\t500\t+")
+	expected := BED{Chrom: "chr1", Start: 100, End: 200, Name: "peak1", Score: 500, Strand: '+', ThickStart: 100, ThickEnd: 200}
+	if err != nil || !reflect.DeepEqual(bed, expected) {
+		t.Errorf("Error: ParseBEDLine() = %+v, %v, expected: %+v", bed, err, expected)
+	}
+	if line := bed.ToString(6); line != "chr1\t100\t200\tpeak1\t500\t+" {
+		t.Errorf("Error: ToString(6) = %q", line)
+	}
+	if _, err = ParseBEDLine("chr1\t100\t200\tx\t0\t+\t100\t200\t0\t2\t10,\t0,90,"); err == nil {
+		t.Errorf("Error: ParseBEDLine() expected an error for mismatched block counts")
+	}
+	if _, err = ParseBEDLine("chr1\t200\t100"); err == nil {
+		t.Errorf("Error: ParseBEDLine() expected an error for an interval ending before it starts")
+	}
+}
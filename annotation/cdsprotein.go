@@ -0,0 +1,235 @@
+package annotation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+	"gopher-proteinlab/protein"
+)
+
+// fastaLineWidth is the number of residues per line of the FASTA files written, as used by UniProt.
+const fastaLineWidth = 60
+
+// ProteinRecord is a protein derived from a CDS feature of a nucleotide record, holding the
+// fields that protein records are usually compared on. It implements Record, so that translated
+// RefSeq or EMBL annotation can be handled by the same tools as UniProt entries.
+type ProteinRecord struct {
+	ProteinID       string           `json:"proteinId"`
+	Product         string           `json:"product,omitempty"`
+	Gene            string           `json:"gene,omitempty"`
+	LocusTag        string           `json:"locusTag,omitempty"`
+	Organism        string           `json:"organism,omitempty"`
+	TaxonID         int              `json:"taxonId,omitempty"`
+	Source          string           `json:"source"`   // Accession of the nucleotide record
+	Location        string           `json:"location"` // Location of the CDS on the nucleotide record
+	CrossReferences []CrossReference `json:"crossReferences,omitempty"`
+	Sequence        string           `json:"sequence"`
+}
+
+// CDSProteins derives a protein record from each CDS feature of the GenBank record.
+func (e *GenBankEntry) CDSProteins() ([]ProteinRecord, error) {
+	return cdsProteins(e, e.Features)
+}
+
+// CDSProteins derives a protein record from each CDS feature of the EMBL entry.
+func (e *EMBLEntry) CDSProteins() ([]ProteinRecord, error) {
+	return cdsProteins(e, e.Features)
+}
+
+// cdsProteins translates the CDS features of a nucleotide record. CDS features that lie on the
+// record are translated from its sequence; others, such as those of CON records, and those that
+// fail to translate, e.g. for an invalid /transl_except, use their /translation qualifier. A CDS
+// that fails to translate without a /translation is returned as an error, while pseudo CDS
+// features and remote CDS features without a /translation are skipped. CDS features without a
+// /protein_id are named after the record and their position among its CDS features.
+func cdsProteins(record Record, features []Feature) ([]ProteinRecord, error) {
+	if isProteinRecord(record) {
+		return nil, fmt.Errorf("%s is a protein record", record.RecordID())
+	}
+	source := record.RecordID()
+	if accessions := record.RecordAccessions(); len(accessions) > 0 {
+		source = accessions[0]
+	}
+	sequence := record.RecordSequence()
+
+	var proteins []ProteinRecord
+	cds := 0 // Number of the CDS feature among those of the record
+	for _, f := range features {
+		if f.Key != "CDS" {
+			continue
+		}
+		cds++
+		if _, pseudo := f.Qualifiers["/pseudo"]; pseudo {
+			continue
+		}
+		loc, err := f.ParseLocation()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", source, err)
+		}
+
+		p := ProteinRecord{
+			ProteinID: firstQualifier(f.Qualifiers, "/protein_id"),
+			Product:   firstQualifier(f.Qualifiers, "/product"),
+			Gene:      firstQualifier(f.Qualifiers, "/gene"),
+			LocusTag:  firstQualifier(f.Qualifiers, "/locus_tag"),
+			Organism:  record.RecordOrganism(),
+			TaxonID:   record.RecordTaxonID(),
+			Source:    source,
+			Location:  f.Location,
+		}
+		if p.ProteinID == "" {
+			p.ProteinID = source + "_cds" + strconv.Itoa(cds)
+		}
+		for _, xref := range f.Values("/db_xref") {
+			if database, id, found := strings.Cut(xref, ":"); found {
+				p.CrossReferences = append(p.CrossReferences, CrossReference{Database: database, ID: id})
+			}
+		}
+
+		translated := false
+		if sequence != "" && loc.End() > 0 && isLocal(loc) {
+			var residues []protein.Protein
+			if residues, err = TranslateCDS(sequence, f); err == nil {
+				p.Sequence, translated = protein.ToString(residues), true
+			}
+		}
+		if !translated {
+			translation, ok := f.Qualifiers["/translation"]
+			if !ok && err != nil {
+				return nil, fmt.Errorf("%s: %v", source, err)
+			}
+			if !ok {
+				continue
+			}
+			p.Sequence = strings.Join(strings.Fields(translation), "")
+		}
+		proteins = append(proteins, p)
+	}
+	return proteins, nil
+}
+
+// isLocal reports whether every span of a location lies on the record itself.
+func isLocal(loc Location) bool {
+	for _, span := range loc.Spans {
+		if span.Accession != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// FastaHeader returns the header line of the protein in the style of UniProt FASTA files,
+// e.g. >NP_000509.1 hemoglobin subunit beta OS=Homo sapiens OX=9606 GN=HBB.
+func (p ProteinRecord) FastaHeader() string {
+	header := ">" + p.ProteinID
+	if p.Product != "" {
+		header += " " + p.Product
+	}
+	if p.Organism != "" {
+		header += " OS=" + p.Organism
+	}
+	if p.TaxonID != 0 {
+		header += " OX=" + strconv.Itoa(p.TaxonID)
+	}
+	if gene := p.Gene; gene != "" || p.LocusTag != "" {
+		if gene == "" {
+			gene = p.LocusTag
+		}
+		header += " GN=" + gene
+	}
+	return header
+}
+
+// ToString formats the protein as a FASTA record with 60 residues per line.
+func (p ProteinRecord) ToString() string {
+	txt := parseio.NewTxtBuilder()
+	txt.WriteString(p.FastaHeader())
+	txt.WriteByte('\n')
+	for i := 0; i < len(p.Sequence); i += fastaLineWidth {
+		txt.WriteString(p.Sequence[i:min(i+fastaLineWidth, len(p.Sequence))])
+		txt.WriteByte('\n')
+	}
+	return txt.String()
+}
+
+// ToJson converts a ProteinRecord to a JSON-formatted string.
+func (p *ProteinRecord) ToJson() string {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		fmt.Printf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// WriteProteinFasta writes proteins as FASTA records.
+func WriteProteinFasta(w io.Writer, proteins []ProteinRecord) error {
+	txt := parseio.NewTxtBuilder()
+	for _, p := range proteins {
+		txt.WriteString(p.ToString())
+	}
+	_, err := io.WriteString(w, txt.String())
+	return err
+}
+
+// WriteProteinJSON writes proteins as an indented JSON array.
+func WriteProteinJSON(w io.Writer, proteins []ProteinRecord) error {
+	if proteins == nil {
+		proteins = []ProteinRecord{}
+	}
+	data, err := json.MarshalIndent(proteins, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// RecordID returns the protein identifier.
+func (p *ProteinRecord) RecordID() string {
+	return p.ProteinID
+}
+
+// RecordAccessions returns the protein identifier without its version.
+func (p *ProteinRecord) RecordAccessions() []string {
+	accession, _, _ := strings.Cut(p.ProteinID, ".")
+	return []string{accession}
+}
+
+// RecordDescription returns the product of the CDS.
+func (p *ProteinRecord) RecordDescription() string {
+	return p.Product
+}
+
+// RecordOrganism returns the organism of the nucleotide record.
+func (p *ProteinRecord) RecordOrganism() string {
+	return p.Organism
+}
+
+// RecordTaxonID returns the taxon of the nucleotide record.
+func (p *ProteinRecord) RecordTaxonID() int {
+	return p.TaxonID
+}
+
+// RecordSequence returns the translated sequence.
+func (p *ProteinRecord) RecordSequence() string {
+	return p.Sequence
+}
+
+// RecordFeatures returns no features, as a CDS carries no annotation in protein coordinates.
+func (p *ProteinRecord) RecordFeatures() ([]RecordFeature, error) {
+	return nil, nil
+}
+
+// RecordCrossReferences returns the /db_xref links of the CDS.
+func (p *ProteinRecord) RecordCrossReferences() []CrossReference {
+	return p.CrossReferences
+}
+
+// IsProtein reports that protein records hold amino acid sequences.
+func (p *ProteinRecord) IsProtein() bool {
+	return true
+}
@@ -0,0 +1,77 @@
+package annotation
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCDSProteins(t *testing.T) {
+	entry := &GenBankEntry{
+		Locus:     "TEST",
+		Accession: []string{"AB000001"},
+		Organism:  "Homo sapiens",
+		Sequence:  "ccATGGCCTGGTAAccTTACCAGGCCATcc",
+		Features: []Feature{
+			{Key: "source", Location: "1..30", Qualifiers: map[string]string{"/organism": "Homo sapiens", "/db_xref": "taxon:9606"}},
+			{Key: "CDS", Location: "3..14", Qualifiers: map[string]string{
+				"/gene": "abc", "/product": "test protein", "/protein_id": "XP_000001.1",
+				"/db_xref": "GeneID:1\nHGNC:HGNC:5", "/translation": "MAW",
+			}},
+			{Key: "CDS", Location: "complement(17..28)", Qualifiers: map[string]string{"/locus_tag": "T_002"}},
+			{Key: "CDS", Location: "join(AB000002.1:1..9,1..3)", Qualifiers: map[string]string{"/protein_id": "XP_000003.1", "/translation": "MKL"}},
+			{Key: "CDS", Location: "3..14", Qualifiers: map[string]string{"/pseudo": ""}},
+			{Key: "CDS", Location: "3..14", Qualifiers: map[string]string{"/transl_except": "(pos:6..8,aa:Xyz)", "/translation": "MSW"}},
+		},
+	}
+	proteins, err := entry.CDSProteins()
+	if err != nil {
+		t.Fatalf("CDSProteins failed: %v", err)
+	}
+	expected := []ProteinRecord{
+		{ProteinID: "XP_000001.1", Product: "test protein", Gene: "abc", Organism: "Homo sapiens", TaxonID: 9606, Source: "AB000001", Location: "3..14",
+			CrossReferences: []CrossReference{{Database: "GeneID", ID: "1"}, {Database: "HGNC", ID: "HGNC:5"}}, Sequence: "MAW"},
+		{ProteinID: "AB000001_cds2", LocusTag: "T_002", Organism: "Homo sapiens", TaxonID: 9606, Source: "AB000001", Location: "complement(17..28)", Sequence: "MAW"},
+		{ProteinID: "XP_000003.1", Organism: "Homo sapiens", TaxonID: 9606, Source: "AB000001", Location: "join(AB000002.1:1..9,1..3)", Sequence: "MKL"},
+		{ProteinID: "AB000001_cds5", Organism: "Homo sapiens", TaxonID: 9606, Source: "AB000001", Location: "3..14", Sequence: "MSW"},
+	}
+	if !reflect.DeepEqual(proteins, expected) {
+		t.Fatalf("Error: CDSProteins() = %+v\nexpected: %+v", proteins, expected)
+	}
+
+	var fasta strings.Builder
+	if err = WriteProteinFasta(&fasta, proteins[:2]); err != nil {
+		t.Fatalf("WriteProteinFasta failed: %v", err)
+	}
+	if expected := ">XP_000001.1 test protein OS=Homo sapiens OX=9606 GN=abc\nMAW\n>AB000001_cds2 OS=Homo sapiens OX=9606 GN=T_002\nMAW\n"; fasta.String() != expected {
+		t.Errorf("Error: WriteProteinFasta() =\n%s\nexpected:\n%s", fasta.String(), expected)
+	}
+	long := ProteinRecord{ProteinID: "P1", Sequence: strings.Repeat("A", 130)}
+	if lines := strings.Split(long.ToString(), "\n"); len(lines) != 5 || len(lines[1]) != 60 || len(lines[3]) != 10 {
+		t.Errorf("Error: ToString() did not wrap at 60 residues: %q", lines)
+	}
+
+	var text strings.Builder
+	if err = WriteProteinJSON(&text, proteins); err != nil {
+		t.Fatalf("WriteProteinJSON failed: %v", err)
+	}
+	var decoded []ProteinRecord
+	if err = json.Unmarshal([]byte(text.String()), &decoded); err != nil || !reflect.DeepEqual(decoded, proteins) {
+		t.Errorf("Error: WriteProteinJSON() did not round trip: %v\n%s", err, text.String())
+	}
+
+	var record Record = &proteins[0]
+	if !isProteinRecord(record) || record.RecordAccessions()[0] != "XP_000001" || record.RecordDescription() != "test protein" {
+		t.Errorf("Error: ProteinRecord does not implement Record as expected")
+	}
+	if _, err = (&GenBankEntry{Locus: "P", MoleculeType: "aa"}).CDSProteins(); err == nil {
+		t.Errorf("Error: CDSProteins() expected an error for a protein record")
+	}
+
+	// A CDS that cannot be translated and has no /translation is reported rather than dropped
+	entry.Features = append(entry.Features, Feature{Key: "CDS", Location: "3..14", Qualifiers: map[string]string{"/transl_except": "(pos:6..8,aa:Xyz)"}})
+	if _, err = entry.CDSProteins(); err == nil || !strings.Contains(err.Error(), "AB000001: feature CDS 3..14: unknown amino acid") {
+		t.Errorf("Error: CDSProteins() returned %v, expected an error for the untranslatable CDS", err)
+	}
+}
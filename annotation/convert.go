@@ -0,0 +1,391 @@
+package annotation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// functionalDivisions are GenBank divisions that EMBL records as a data class rather than a
+// taxonomic division.
+var functionalDivisions = map[string]bool{
+	"CON": true, "EST": true, "GSS": true, "HTC": true, "HTG": true, "PAT": true, "STS": true, "TSA": true,
+}
+
+// genBankToEMBLDivision maps GenBank taxonomic divisions to EMBL divisions. PRI, ROD and PLN
+// depend on the organism and are resolved by emblDivision.
+var genBankToEMBLDivision = map[string]string{
+	"BCT": "PRO", "ENV": "ENV", "INV": "INV", "MAM": "MAM", "PHG": "PHG", "PLN": "PLN",
+	"PRI": "MAM", "ROD": "ROD", "SYN": "SYN", "UNA": "UNC", "VRL": "VRL", "VRT": "VRT",
+}
+
+// emblToGenBankDivision maps EMBL taxonomic divisions to GenBank divisions. MAM depends on the
+// organism and is resolved by genBankDivision.
+var emblToGenBankDivision = map[string]string{
+	"ENV": "ENV", "FUN": "PLN", "HUM": "PRI", "INV": "INV", "MAM": "MAM", "MUS": "ROD", "PHG": "PHG",
+	"PLN": "PLN", "PRO": "BCT", "ROD": "ROD", "SYN": "SYN", "TGN": "SYN", "UNC": "UNA", "VRL": "VRL", "VRT": "VRT",
+}
+
+// basesRange matches one range of a GenBank REFERENCE line, e.g. "1 to 655".
+var basesRange = regexp.MustCompile(`(\d+) to (\d+)`)
+
+// positionsRange matches one range of an EMBL RP line, e.g. "1-655".
+var positionsRange = regexp.MustCompile(`(\d+)-(\d+)`)
+
+// GenBankToEMBL converts a GenBank record to an EMBL entry. The header, references, taxonomy and
+// DBLINK cross-references are mapped to their EMBL counterparts and the feature table is copied.
+// The PRIMARY table of RefSeq records has no EMBL counterpart and is dropped.
+func GenBankToEMBL(entry *GenBankEntry) *EMBLEntry {
+	embl := &EMBLEntry{
+		ID:           entry.Locus,
+		Topology:     entry.Topology,
+		MoleculeType: emblMoleculeType(entry),
+		DataClass:    "STD",
+		Division:     emblDivision(entry),
+		Length:       entry.Length,
+		Accession:    append([]string(nil), entry.Accession...),
+		Description:  strings.TrimSuffix(entry.Definition, "."),
+		Keywords:     append([]string(nil), entry.Keywords...),
+		Source:       entry.Source,
+		Taxonomy:     append([]string(nil), entry.Taxonomy...),
+		Comment:      entry.Comment,
+		Features:     copyFeatures(entry.Features),
+		Contig:       entry.Contig,
+		Sequence:     entry.Sequence,
+	}
+	if len(entry.Accession) > 0 {
+		embl.ID = entry.Accession[0]
+	}
+	if fields := strings.Fields(entry.Version); len(fields) > 0 {
+		if i := strings.LastIndex(fields[0], "."); i >= 0 {
+			embl.Version = fields[0][i+1:]
+		}
+	}
+	if functionalDivisions[entry.Division] {
+		embl.DataClass = entry.Division
+	}
+	if entry.Date != "" {
+		embl.Dates = []string{entry.Date}
+	}
+	if embl.Source == "" {
+		embl.Source = entry.Organism
+	}
+	if organelle := firstValue(entry.Features, "/organelle"); organelle != "" {
+		embl.Organelle = organelle
+	}
+
+	for _, link := range entry.DBLink {
+		database, ids, found := strings.Cut(link, ":")
+		if !found {
+			continue
+		}
+		for _, id := range strings.Split(ids, ",") {
+			if id = strings.TrimSpace(id); id == "" {
+				continue
+			}
+			if database == "BioProject" && embl.Project == "" {
+				embl.Project = "Project:" + id
+			} else {
+				embl.CrossReferences = append(embl.CrossReferences, database+"; "+id)
+			}
+		}
+	}
+
+	for _, ref := range entry.References {
+		reference := EMBLReference{
+			Number:   ref.Number,
+			Comment:  ref.Remark,
+			Group:    ref.Consortium,
+			Authors:  emblAuthors(ref.Authors),
+			Title:    ref.Title,
+			Location: emblJournal(ref.Journal),
+		}
+		var positions []string
+		for _, match := range basesRange.FindAllStringSubmatch(ref.Bases, -1) {
+			positions = append(positions, match[1]+"-"+match[2])
+		}
+		reference.Positions = strings.Join(positions, ", ")
+		if ref.Medline != "" {
+			reference.CrossReferences = append(reference.CrossReferences, "MEDLINE; "+ref.Medline)
+		}
+		if ref.PubMed != "" {
+			reference.CrossReferences = append(reference.CrossReferences, "PUBMED; "+ref.PubMed)
+		}
+		embl.References = append(embl.References, reference)
+	}
+	return embl
+}
+
+// EMBLToGenBank converts an EMBL entry to a GenBank record. The header, references, taxonomy and
+// cross-references are mapped to their GenBank counterparts and the feature table is copied.
+// Reference cross-references other than PubMed and MEDLINE have no GenBank equivalent and are dropped.
+func EMBLToGenBank(entry *EMBLEntry) *GenBankEntry {
+	genbank := &GenBankEntry{
+		Locus:        entry.ID,
+		Length:       entry.Length,
+		MoleculeType: genBankMoleculeType(entry.MoleculeType),
+		Topology:     entry.Topology,
+		Division:     genBankDivision(entry),
+		Accession:    append([]string(nil), entry.Accession...),
+		Keywords:     append([]string(nil), entry.Keywords...),
+		Source:       entry.Source,
+		Organism:     entry.Source,
+		Taxonomy:     append([]string(nil), entry.Taxonomy...),
+		Comment:      entry.Comment,
+		Features:     copyFeatures(entry.Features),
+		Contig:       entry.Contig,
+		Sequence:     entry.Sequence,
+	}
+	if genbank.Length == 0 {
+		genbank.Length = len(entry.Sequence)
+	}
+	if entry.Description != "" {
+		genbank.Definition = strings.TrimSuffix(entry.Description, ".") + "."
+	}
+	if accession := entry.ID; entry.Version != "" {
+		if len(entry.Accession) > 0 {
+			accession = entry.Accession[0]
+		}
+		genbank.Version = accession + "." + entry.Version
+	}
+	if n := len(entry.Dates); n > 0 {
+		genbank.Date, _, _ = strings.Cut(entry.Dates[n-1], " ")
+	}
+	// Drop the common name from "Homo sapiens (human)"
+	if i := strings.Index(entry.Source, " ("); i > 0 && strings.HasSuffix(entry.Source, ")") {
+		genbank.Organism = entry.Source[:i]
+	}
+
+	if project := strings.TrimPrefix(entry.Project, "Project:"); project != "" {
+		genbank.DBLink = append(genbank.DBLink, "BioProject: "+project)
+	}
+	var databases []string
+	ids := make(map[string][]string)
+	for _, xref := range entry.CrossReferences {
+		fields := strings.Split(xref, ";")
+		if len(fields) < 2 {
+			continue
+		}
+		database := strings.TrimSpace(fields[0])
+		if _, ok := ids[database]; !ok {
+			databases = append(databases, database)
+		}
+		ids[database] = append(ids[database], strings.TrimSpace(fields[1]))
+	}
+	for _, database := range databases {
+		genbank.DBLink = append(genbank.DBLink, database+": "+strings.Join(ids[database], ", "))
+	}
+
+	for _, ref := range entry.References {
+		reference := GenBankReference{
+			Number:     ref.Number,
+			Authors:    genBankAuthors(ref.Authors),
+			Consortium: ref.Group,
+			Title:      ref.Title,
+			Journal:    genBankJournal(ref.Location),
+			Remark:     ref.Comment,
+		}
+		var bases []string
+		for _, match := range positionsRange.FindAllStringSubmatch(ref.Positions, -1) {
+			bases = append(bases, match[1]+" to "+match[2])
+		}
+		if len(bases) > 0 {
+			reference.Bases = "bases " + strings.Join(bases, "; ")
+		}
+		for _, xref := range ref.CrossReferences {
+			database, id, _ := strings.Cut(xref, ";")
+			switch strings.TrimSpace(database) {
+			case "PUBMED":
+				reference.PubMed = strings.TrimSpace(id)
+			case "MEDLINE":
+				reference.Medline = strings.TrimSpace(id)
+			}
+		}
+		genbank.References = append(genbank.References, reference)
+	}
+	return genbank
+}
+
+// emblMoleculeType returns the INSDC molecule type of a GenBank record, preferring the /mol_type
+// of its source feature over the LOCUS line.
+func emblMoleculeType(entry *GenBankEntry) string {
+	if molType := firstValue(entry.Features, "/mol_type"); molType != "" {
+		return molType
+	}
+	molecule := entry.MoleculeType
+	if len(molecule) > 3 && molecule[2] == '-' {
+		molecule = molecule[3:]
+	}
+	switch molecule {
+	case "DNA":
+		return "genomic DNA"
+	case "RNA":
+		return "unassigned RNA"
+	}
+	return molecule
+}
+
+// genBankMoleculeType returns the LOCUS line molecule type for an INSDC molecule type.
+func genBankMoleculeType(molType string) string {
+	switch {
+	case molType == "mRNA", molType == "tRNA", molType == "rRNA":
+		return molType
+	case strings.HasSuffix(molType, "DNA"):
+		return "DNA"
+	case strings.HasSuffix(molType, "RNA"):
+		return "RNA"
+	}
+	return molType
+}
+
+// emblDivision returns the EMBL taxonomic division of a GenBank record. Functional divisions such
+// as CON carry no taxonomic information, so the division is then inferred from the lineage.
+func emblDivision(entry *GenBankEntry) string {
+	division := entry.Division
+	if functionalDivisions[division] {
+		division = lineageDivision(entry.Taxonomy)
+	}
+	switch {
+	case division == "PRI" && entry.Organism == "Homo sapiens":
+		return "HUM"
+	case division == "ROD" && entry.Organism == "Mus musculus":
+		return "MUS"
+	case division == "PLN" && hasTaxon(entry.Taxonomy, "Fungi"):
+		return "FUN"
+	}
+	if embl, ok := genBankToEMBLDivision[division]; ok {
+		return embl
+	}
+	return "UNC"
+}
+
+// genBankDivision returns the GenBank division of an EMBL entry, using the data class for
+// non-standard entries such as CON.
+func genBankDivision(entry *EMBLEntry) string {
+	if functionalDivisions[entry.DataClass] {
+		return entry.DataClass
+	}
+	if entry.Division == "MAM" && hasTaxon(entry.Taxonomy, "Primates") {
+		return "PRI"
+	}
+	if division, ok := emblToGenBankDivision[entry.Division]; ok {
+		return division
+	}
+	return "UNA"
+}
+
+// lineageDivision infers the GenBank taxonomic division from a taxonomic lineage.
+func lineageDivision(taxonomy []string) string {
+	switch {
+	case hasTaxon(taxonomy, "Primates"):
+		return "PRI"
+	case hasTaxon(taxonomy, "Rodentia"):
+		return "ROD"
+	case hasTaxon(taxonomy, "Mammalia"):
+		return "MAM"
+	case hasTaxon(taxonomy, "Vertebrata"):
+		return "VRT"
+	case hasTaxon(taxonomy, "Viruses"):
+		return "VRL"
+	case hasTaxon(taxonomy, "Bacteria"), hasTaxon(taxonomy, "Archaea"):
+		return "BCT"
+	case hasTaxon(taxonomy, "Viridiplantae"), hasTaxon(taxonomy, "Fungi"):
+		return "PLN"
+	case hasTaxon(taxonomy, "Eukaryota"):
+		return "INV"
+	}
+	return "UNA"
+}
+
+// hasTaxon reports whether a lineage contains the taxon.
+func hasTaxon(taxonomy []string, taxon string) bool {
+	for _, name := range taxonomy {
+		if name == taxon {
+			return true
+		}
+	}
+	return false
+}
+
+// emblAuthors converts a GenBank author list, "Haas,A., Smith,B.C. and Goebel,W.", to the EMBL
+// form, "Haas A., Smith B.C., Goebel W.".
+func emblAuthors(authors string) string {
+	if authors == "" {
+		return ""
+	}
+	if i := strings.LastIndex(authors, " and "); i >= 0 {
+		authors = authors[:i] + ", " + authors[i+5:]
+	}
+	names := strings.Split(authors, ", ")
+	for i, name := range names {
+		names[i] = strings.Replace(name, ",", " ", 1)
+	}
+	return strings.Join(names, ", ")
+}
+
+// genBankAuthors converts an EMBL author list to the GenBank form, reversing emblAuthors.
+func genBankAuthors(authors string) string {
+	if authors == "" {
+		return ""
+	}
+	names := strings.Split(authors, ", ")
+	for i, name := range names {
+		if j := strings.LastIndex(name, " "); j >= 0 {
+			names[i] = name[:j] + "," + name[j+1:]
+		}
+	}
+	if n := len(names); n > 1 {
+		return strings.Join(names[:n-1], ", ") + " and " + names[n-1]
+	}
+	return names[0]
+}
+
+// emblJournal converts a GenBank JOURNAL line to an EMBL RL line, adding the INSDC wording of
+// direct submissions.
+func emblJournal(journal string) string {
+	if date, rest, found := strings.Cut(journal, ") "); found && strings.HasPrefix(journal, "Submitted (") &&
+		!strings.HasPrefix(rest, "to the INSDC.") {
+		return fmt.Sprintf("%s) to the INSDC. %s", date, rest)
+	}
+	return journal
+}
+
+// genBankJournal converts an EMBL RL line to a GenBank JOURNAL line.
+func genBankJournal(location string) string {
+	location = strings.ReplaceAll(location, "\n", " ")
+	if strings.HasPrefix(location, "Submitted (") {
+		location = strings.Replace(location, ") to the INSDC. ", ") ", 1)
+	}
+	return location
+}
+
+// firstValue returns the first value of a qualifier in the source feature, or of any feature if
+// there is no source feature.
+func firstValue(features []Feature, key string) string {
+	for _, feature := range features {
+		if values := feature.Values(key); feature.Key == "source" && len(values) > 0 {
+			return values[0]
+		}
+	}
+	for _, feature := range features {
+		if values := feature.Values(key); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// copyFeatures returns a copy of a feature table that shares no qualifier maps with the original.
+func copyFeatures(features []Feature) []Feature {
+	if features == nil {
+		return nil
+	}
+	copied := make([]Feature, len(features))
+	for i, feature := range features {
+		copied[i] = Feature{Key: feature.Key, Location: feature.Location, Qualifiers: make(map[string]string, len(feature.Qualifiers))}
+		for key, value := range feature.Qualifiers {
+			copied[i].Qualifiers[key] = value
+		}
+	}
+	return copied
+}
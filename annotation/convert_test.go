@@ -0,0 +1,108 @@
+package annotation
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+func TestGenBankToEMBL(t *testing.T) {
+	reader := NewGenBankReader("testdata/human.biological_region.gbff.gz")
+	defer reader.Close()
+
+	for count := 0; ; count++ {
+		entry, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed after %d records: %v", count, err)
+		}
+		embl := GenBankToEMBL(entry)
+		if count == 0 {
+			if embl.ID != "NG_055818" || embl.Version != "1" || embl.DataClass != "CON" || embl.Division != "HUM" ||
+				embl.MoleculeType != "genomic DNA" || embl.Project != "Project:PRJNA343958" {
+				t.Errorf("Error: GenBankToEMBL() header = %s; SV %s; %s; %s; %s; PR %s",
+					embl.ID, embl.Version, embl.MoleculeType, embl.DataClass, embl.Division, embl.Project)
+			}
+			if ref := embl.References[0]; ref.Positions != "1-655" || !reflect.DeepEqual(ref.CrossReferences, []string{"PUBMED; 34158671"}) ||
+				ref.Authors != "Nekolova J., Stepanov A., Kousal B., Stredova M., Jiraskova N." {
+				t.Errorf("Error: GenBankToEMBL() reference = %+v", ref)
+			}
+		}
+
+		// Write the EMBL entry, read it back and convert it again
+		var text strings.Builder
+		if err = WriteEMBL(&text, embl); err != nil {
+			t.Fatalf("WriteEMBL failed: %v", err)
+		}
+		scanner := &parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(text.String()))}
+		parsed, err := ParseEMBL(scanner)
+		if err != nil {
+			t.Fatalf("ParseEMBL failed: %v", err)
+		}
+		// PRIMARY tables have no EMBL counterpart and a few records list authors in the EMBL
+		// style, which converts back to the GenBank style
+		entry.Primary = ""
+		for i := range entry.References {
+			entry.References[i].Authors = genBankAuthors(emblAuthors(entry.References[i].Authors))
+		}
+		if converted := EMBLToGenBank(parsed); !reflect.DeepEqual(converted, entry) {
+			t.Fatalf("Error: %s did not round trip.\nConverted: %s\nExpected: %s", entry.Locus, converted.ToJson(), entry.ToJson())
+		}
+	}
+}
+
+func TestEMBLToGenBank(t *testing.T) {
+	entry := &EMBLEntry{
+		ID:              "X56734",
+		Version:         "1",
+		Topology:        "linear",
+		MoleculeType:    "mRNA",
+		DataClass:       "STD",
+		Division:        "PLN",
+		Length:          1859,
+		Accession:       []string{"X56734", "S46826"},
+		Dates:           []string{"12-SEP-1991 (Rel. 29, Created)", "25-NOV-2005 (Rel. 85, Last updated, Version 11)"},
+		Description:     "Trifolium repens mRNA for non-cyanogenic beta-glucosidase",
+		Source:          "Trifolium repens (white clover)",
+		Taxonomy:        []string{"Eukaryota", "Viridiplantae", "Streptophyta"},
+		CrossReferences: []string{"MD5; 1e51ca3a5450c43524b9185c236cc5cc", "BioSample; SAMEA1", "BioSample; SAMEA2"},
+		References: []EMBLReference{{
+			Number:          "2",
+			Positions:       "1-1859",
+			CrossReferences: []string{"DOI; 10.1007/BF00039495", "PUBMED; 1907511"},
+			Authors:         "Oxtoby E., Dunn M.A., Pancoro A., Hughes M.A.",
+			Location:        "Submitted (12-SEP-1991) to the INSDC. Oxtoby E., University of Leeds,\nLeeds, UK.",
+		}},
+	}
+	expected := &GenBankEntry{
+		Locus:        "X56734",
+		Length:       1859,
+		MoleculeType: "mRNA",
+		Topology:     "linear",
+		Division:     "PLN",
+		Date:         "25-NOV-2005",
+		Definition:   "Trifolium repens mRNA for non-cyanogenic beta-glucosidase.",
+		Accession:    []string{"X56734", "S46826"},
+		Version:      "X56734.1",
+		DBLink:       []string{"MD5: 1e51ca3a5450c43524b9185c236cc5cc", "BioSample: SAMEA1, SAMEA2"},
+		Source:       "Trifolium repens (white clover)",
+		Organism:     "Trifolium repens",
+		Taxonomy:     []string{"Eukaryota", "Viridiplantae", "Streptophyta"},
+		References: []GenBankReference{{
+			Number:  "2",
+			Bases:   "bases 1 to 1859",
+			Authors: "Oxtoby,E., Dunn,M.A., Pancoro,A. and Hughes,M.A.",
+			Journal: "Submitted (12-SEP-1991) Oxtoby E., University of Leeds, Leeds, UK.",
+			PubMed:  "1907511",
+		}},
+	}
+	if genbank := EMBLToGenBank(entry); !reflect.DeepEqual(genbank, expected) {
+		t.Errorf("Error: EMBLToGenBank() = %s, expected: %s", genbank.ToJson(), expected.ToJson())
+	}
+}
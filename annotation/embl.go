@@ -3,117 +3,283 @@ package annotation
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"gopher-proteinlab/parseio"
-	"strings"
 )
 
 // EMBLEntry represents the structure of an EMBL file entry.
 type EMBLEntry struct {
-	ID        string    `json:"id"`
-	Accession []string  `json:"accession"`
-	Keywords  []string  `json:"keywords"`
-	Source    string    `json:"source"`
-	Features  []Feature `json:"features"`
-	Sequence  string    `json:"sequence"`
+	ID              string          `json:"id"`
+	Version         string          `json:"version,omitempty"`
+	Topology        string          `json:"topology,omitempty"`
+	MoleculeType    string          `json:"moleculeType,omitempty"`
+	DataClass       string          `json:"dataClass,omitempty"`
+	Division        string          `json:"division,omitempty"`
+	Length          int             `json:"length,omitempty"`
+	Accession       []string        `json:"accession"`
+	Project         string          `json:"project,omitempty"`
+	Dates           []string        `json:"dates,omitempty"`
+	Description     string          `json:"description,omitempty"`
+	Keywords        []string        `json:"keywords"`
+	Source          string          `json:"source"`
+	Taxonomy        []string        `json:"taxonomy,omitempty"`
+	Organelle       string          `json:"organelle,omitempty"`
+	References      []EMBLReference `json:"references,omitempty"`
+	CrossReferences []string        `json:"crossReferences,omitempty"`
+	Comment         string          `json:"comment,omitempty"`
+	Features        []Feature       `json:"features"`
+	Contig          string          `json:"contig,omitempty"`
+	Sequence        string          `json:"sequence"`
+}
+
+// EMBLReference represents the RN block of an EMBL entry.
+type EMBLReference struct {
+	Number          string   `json:"number"`
+	Comment         string   `json:"comment,omitempty"`
+	Positions       string   `json:"positions,omitempty"`
+	CrossReferences []string `json:"crossReferences,omitempty"`
+	Group           string   `json:"group,omitempty"`
+	Authors         string   `json:"authors,omitempty"`
+	Title           string   `json:"title,omitempty"`
+	Location        string   `json:"location,omitempty"`
+}
+
+// EMBLReader streams the entries of a plain or gzipped EMBL (.dat) file one at a time.
+type EMBLReader struct {
+	flatFileReader
 }
 
-// EMBLReader reads an EMBL .dat file, decodes its content, and returns the parsed data one entry at a time.
-func EMBLReader(filename string) {
-	emblScanner := parseio.NewScanner(filename) // This will be a wrapper around bufio.Scanner
-	defer emblScanner.Close()
+// emblLineCodes lists the line codes of an EMBL entry. Codes that are not stored in an EMBLEntry
+// are skipped.
+var emblLineCodes = map[string]bool{
+	"ID": true, "AC": true, "PR": true, "DT": true, "DE": true, "KW": true, "OS": true, "OC": true,
+	"OG": true, "RN": true, "RC": true, "RP": true, "RX": true, "RG": true, "RA": true, "RT": true,
+	"RL": true, "DR": true, "CC": true, "AH": true, "AS": true, "FH": true, "FT": true, "CO": true,
+	"SQ": true, "XX": true, "SV": true, "NI": true, "//": true,
+}
+
+// NewEMBLReader opens an EMBL file for reading.
+func NewEMBLReader(filename string) *EMBLReader {
+	return &EMBLReader{flatFileReader{
+		filename: filename,
+		scanner:  parseio.NewScanner(filename),
+	}}
+}
+
+// Read returns the next entry of the file, or io.EOF once every entry has been read.
+// Malformed entries are returned as a *ParseError in strict mode and skipped in lenient mode.
+func (r *EMBLReader) Read() (*EMBLEntry, error) {
 	for {
-		// Parse each entry and process it
-		entry, err := parseEMBL(emblScanner)
-		if err == io.EOF {
-			break // End of file reached
+		r.lines = RecordLines{}
+		entry, err := parseEMBL(r.scanner, &r.lines)
+		if err == nil || err == io.EOF {
+			return entry, err
+		}
+		if skipped, err := r.recover(err, "ID   "); !skipped {
+			return nil, err
 		}
-		parseio.ExitOnError(err)
-		// Process the entry, e.g., print or store it
-		fmt.Println(entry.ToString())
 	}
 }
 
-// parseEMBL parses one EMBL entry at a time from the provided scanner.
-func parseEMBL(scanner *parseio.Scanalyzer) (*EMBLEntry, error) {
-	entry := &EMBLEntry{}
-	currentFeature := Feature{}
-
-	for scanner.Scan() {
-		line := scanner.Text()
+// ParseEMBL parses one EMBL entry at a time from the provided scanner, returning io.EOF once
+// there are no more entries. Malformed input is reported as a *ParseError.
+func ParseEMBL(scanner *parseio.Scanalyzer) (*EMBLEntry, error) {
+	return parseEMBL(scanner, &RecordLines{})
+}
 
-		// ID line (entry ID)
-		if strings.HasPrefix(line, "ID") {
-			entry.ID = strings.TrimRight(strings.TrimSpace(line[5:]), ";")
+// parseEMBL parses the next EMBL entry and stores the line numbers of its parts in lines.
+func parseEMBL(scanner *parseio.Scanalyzer, lines *RecordLines) (*EMBLEntry, error) {
+	var entry *EMBLEntry
+	fail := func(err error) (*EMBLEntry, error) {
+		var id string
+		if entry != nil {
+			id = entry.ID
 		}
+		return nil, newParseError(scanner, id, err)
+	}
 
-		// AC line (accession numbers)
-		if strings.HasPrefix(line, "AC") {
-			accessions := strings.Split(strings.TrimRight(line[5:], ";"), ";")
-			for _, acc := range accessions {
-				entry.Accession = append(entry.Accession, strings.TrimSpace(acc))
+	for next, ok := scanner.Peek(); ok; next, ok = scanner.Peek() {
+		if entry == nil {
+			if strings.TrimSpace(next) == "" {
+				scanner.Scan()
+				continue
 			}
+			if !strings.HasPrefix(next, "ID   ") {
+				scanner.Scan()
+				return fail(fmt.Errorf("expected ID line"))
+			}
+			entry = &EMBLEntry{}
+		} else if strings.HasPrefix(next, "ID   ") {
+			return nil, newTruncatedError(scanner, entry.ID, next)
 		}
 
-		// KW line (keywords)
-		if strings.HasPrefix(line, "KW") {
-			keywords := strings.Split(strings.TrimRight(line[5:], ";"), ";")
-			for _, keyword := range keywords {
-				entry.Keywords = append(entry.Keywords, strings.TrimSpace(keyword))
+		// FT lines (features) are read as a whole table
+		if strings.HasPrefix(next, "FT   ") {
+			features, err := readFeatures(scanner, "FT   ", lines)
+			if err != nil {
+				return fail(err)
 			}
+			entry.Features = append(entry.Features, features...)
+			continue
 		}
+		scanner.Scan()
 
-		// FT line (features)
-		if strings.HasPrefix(line, "FT") {
-			ftLine := strings.TrimSpace(line[5:])
-			if strings.HasPrefix(ftLine, "source") || strings.HasPrefix(ftLine, "CDS") {
-				// Append the current feature if it exists
-				if currentFeature.Key != "" {
-					entry.Features = append(entry.Features, currentFeature)
-				}
-				// Create new feature
-				currentFeature = Feature{
-					Key:        strings.Fields(ftLine)[0],
-					Location:   strings.Fields(ftLine)[1],
-					Qualifiers: make(map[string]string),
-				}
-			} else {
-				// Handle qualifier lines
-				if strings.HasPrefix(ftLine, "/") {
-					parts := strings.SplitN(ftLine, "=", 2)
-					if len(parts) == 2 {
-						qualifierKey := strings.TrimSpace(parts[0])
-						qualifierValue := strings.Trim(strings.TrimSpace(parts[1]), "\"")
-						currentFeature.Qualifiers[qualifierKey] = qualifierValue
-					}
-				}
+		code, value := splitLineCode(next)
+		if !emblLineCodes[code] || (code != "//" && len(next) > 2 && !strings.HasPrefix(next[2:], "   ")) {
+			return fail(fmt.Errorf("unknown line code %q", code))
+		}
+		switch code {
+		case "ID":
+			lines.Record = scanner.Line()
+			if err := parseEMBLID(entry, value); err != nil {
+				return fail(err)
 			}
+		case "AC":
+			entry.Accession = append(entry.Accession, splitList(readLineCode(scanner, code, value, " "))...)
+		case "PR":
+			entry.Project = strings.TrimSuffix(value, ";")
+		case "DT":
+			entry.Dates = append(entry.Dates, value)
+		case "DE":
+			entry.Description = readLineCode(scanner, code, value, " ")
+		case "KW":
+			entry.Keywords = splitList(strings.TrimSuffix(readLineCode(scanner, code, value, " "), "."))
+		case "OS":
+			entry.Source = readLineCode(scanner, code, value, " ")
+		case "OC":
+			entry.Taxonomy = splitList(strings.TrimSuffix(readLineCode(scanner, code, value, " "), "."))
+		case "OG":
+			entry.Organelle = value
+		case "RN":
+			entry.References = append(entry.References, readEMBLReference(scanner, value))
+		case "DR":
+			entry.CrossReferences = append(entry.CrossReferences, strings.TrimSuffix(value, "."))
+		case "CC":
+			entry.Comment = readLineCode(scanner, code, value, "\n")
+		case "CO":
+			entry.Contig = readLineCode(scanner, code, value, "")
+		case "SQ":
+			lines.Sequence = scanner.Line()
+			sequence, err := readEMBLSequence(scanner)
+			if err != nil {
+				return fail(err)
+			}
+			entry.Sequence = sequence
+		case "//":
+			return entry, nil
 		}
+	}
 
-		// SQ line (sequence)
-		if strings.HasPrefix(line, "SQ") {
-			var sequence strings.Builder
-			for scanner.Scan() {
-				seqLine := scanner.Text()
-				if seqLine == "//" {
-					break
-				}
-				sequence.WriteString(strings.TrimSpace(seqLine))
-			}
-			entry.Sequence = sequence.String()
+	if entry == nil {
+		return nil, endOfInput(scanner, false, "")
+	}
+	return nil, endOfInput(scanner, true, entry.ID)
+}
+
+// splitLineCode splits an EMBL line into its two letter line code and the value from column 6.
+func splitLineCode(line string) (string, string) {
+	if len(line) < 2 {
+		return line, ""
+	}
+	if len(line) <= 5 {
+		return line[:2], ""
+	}
+	return line[:2], strings.TrimSpace(line[5:])
+}
+
+// readLineCode reads the value of a line code continued on the following lines with the same code,
+// joining the lines with sep.
+func readLineCode(scanner *parseio.Scanalyzer, code string, first string, sep string) string {
+	lines := []string{first}
+	for next, ok := scanner.Peek(); ok && strings.HasPrefix(next, code) && (len(next) == 2 || next[2] == ' '); next, ok = scanner.Peek() {
+		scanner.Scan()
+		_, value := splitLineCode(next)
+		lines = append(lines, value)
+	}
+	return strings.Join(lines, sep)
+}
+
+// splitList splits a semicolon separated list, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
+}
 
-	// Append the last feature if it exists
-	if currentFeature.Key != "" {
-		entry.Features = append(entry.Features, currentFeature)
+// parseEMBLID reads the ID line: accession; SV version; topology; molecule type; data class;
+// taxonomic division; length BP. ID lines in other layouts only set the ID.
+func parseEMBLID(entry *EMBLEntry, value string) error {
+	fields := strings.Split(strings.TrimSuffix(value, "."), ";")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if entry.ID = fields[0]; entry.ID == "" {
+		return fmt.Errorf("ID line has no identifier")
 	}
+	if len(fields) != 7 {
+		return nil
+	}
+	entry.Version = strings.TrimSpace(strings.TrimPrefix(fields[1], "SV"))
+	entry.Topology, entry.MoleculeType, entry.DataClass, entry.Division = fields[2], fields[3], fields[4], fields[5]
+	length, err := strconv.Atoi(strings.TrimSpace(strings.TrimSuffix(fields[6], "BP")))
+	if err != nil {
+		return fmt.Errorf("invalid ID length %q", fields[6])
+	}
+	entry.Length = length
+	return nil
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %v", err)
+// readEMBLReference reads the R* lines that follow an RN line.
+func readEMBLReference(scanner *parseio.Scanalyzer, number string) EMBLReference {
+	reference := EMBLReference{Number: strings.Trim(number, "[]")}
+	for next, ok := scanner.Peek(); ok && strings.HasPrefix(next, "R") && !strings.HasPrefix(next, "RN"); next, ok = scanner.Peek() {
+		scanner.Scan()
+		code, value := splitLineCode(next)
+		switch code {
+		case "RC":
+			reference.Comment = readLineCode(scanner, code, value, " ")
+		case "RP":
+			reference.Positions = readLineCode(scanner, code, value, " ")
+		case "RX":
+			reference.CrossReferences = append(reference.CrossReferences, strings.TrimSuffix(value, "."))
+		case "RG":
+			reference.Group = readLineCode(scanner, code, value, " ")
+		case "RA":
+			reference.Authors = strings.TrimSuffix(readLineCode(scanner, code, value, " "), ";")
+		case "RT":
+			reference.Title = strings.Trim(strings.TrimSuffix(readLineCode(scanner, code, value, " "), ";"), `"`)
+		case "RL":
+			reference.Location = readLineCode(scanner, code, value, "\n")
+		}
 	}
+	return reference
+}
 
-	return entry, nil
+// readEMBLSequence reads the sequence lines following the SQ line, dropping the position numbers.
+func readEMBLSequence(scanner *parseio.Scanalyzer) (string, error) {
+	var sequence strings.Builder
+	for next, ok := scanner.Peek(); ok && strings.HasPrefix(next, " "); next, ok = scanner.Peek() {
+		scanner.Scan()
+		fields := strings.Fields(next)
+		if n := len(fields); n > 1 {
+			if _, err := strconv.Atoi(fields[n-1]); err == nil {
+				fields = fields[:n-1]
+			}
+		}
+		for _, field := range fields {
+			if !isLetters(field) {
+				return "", fmt.Errorf("invalid sequence %q", field)
+			}
+			sequence.WriteString(field)
+		}
+	}
+	return sequence.String(), nil
 }
 
 // EqualEmblEntry is a helper function to compare two EMBLEntry structs.
@@ -61,7 +61,7 @@ SQ   Sequence 1234 BP; 614 A; 324 C; 170 G; 126 T; 0 other;
 		Scanner: bufio.NewScanner(emblReader), // Wrap the bufio.Scanner
 	}
 	// Call the parseEMBL function
-	entry, err := parseEMBL(emblScanner)
+	entry, err := ParseEMBL(emblScanner)
 	if err != nil {
 		t.Fatalf("parseEMBL failed: %v", err)
 	}
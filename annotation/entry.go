@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
-
-	"gopher-proteinlab/parseio"
 )
 
 // Entry defines the interface for parsing biological entries like UniProt and EMBL.
@@ -37,8 +35,10 @@ func (e EMBLEntry) ToString() string {
 	var sb strings.Builder
 	writeField(&sb, "ID: ", e.ID)
 	writeField(&sb, "Accession: ", strings.Join(e.Accession, ", "))
+	writeField(&sb, "Description: ", e.Description)
 	writeField(&sb, "Keywords: ", strings.Join(e.Keywords, ", "))
 	writeField(&sb, "Source: ", e.Source)
+	writeField(&sb, "Taxonomy: ", strings.Join(e.Taxonomy, "; "))
 	sb.WriteString("Features:\n")
 	for _, feature := range e.Features {
 		writeField(&sb, "  Key: ", feature.Key)
@@ -67,9 +67,13 @@ func (e GenBankEntry) ToString() string {
 	writeField("Definition: ", e.Definition)
 	writeField("Accession: ", strings.Join(e.Accession, ", "))
 	writeField("Version: ", e.Version)
+	if e.DBSource != "" {
+		writeField("DBSource: ", e.DBSource)
+	}
 	writeField("Keywords: ", strings.Join(e.Keywords, ", "))
 	writeField("Source: ", e.Source)
 	writeField("Organism: ", e.Organism)
+	writeField("Taxonomy: ", strings.Join(e.Taxonomy, "; "))
 
 	// Write features
 	sb.WriteString("Features:\n")
@@ -86,5 +90,7 @@ func (e GenBankEntry) ToString() string {
 
 // writeField is a helper function to write a label and its corresponding value to a string builder.
 func writeField(buffer *strings.Builder, label, value string) {
-	parseio.NewTxtBuilder().WriteTag(label, value)
+	buffer.WriteString(label)
+	buffer.WriteString(value)
+	buffer.WriteByte('\n')
 }
@@ -1,7 +1,57 @@
 package annotation
 
+import (
+	"fmt"
+	"strings"
+)
+
+// Feature represents one entry of an INSDC feature table shared by EMBL and GenBank files.
+// Qualifier keys keep their leading slash (e.g., /gene) and a qualifier that is repeated, such
+// as /db_xref, holds all of its values separated by newlines.
 type Feature struct {
 	Key        string
 	Location   string
 	Qualifiers map[string]string
 }
+
+// Values returns every value of a possibly repeated qualifier, or nil if it is absent.
+func (f Feature) Values(key string) []string {
+	value, ok := f.Qualifiers[key]
+	if !ok {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
+
+// ParseLocation parses the location string of the feature.
+func (f Feature) ParseLocation() (Location, error) {
+	loc, err := ParseLocation(f.Location)
+	if err != nil {
+		return Location{}, fmt.Errorf("feature %s: %v", f.Key, err)
+	}
+	return loc, nil
+}
+
+// ExtractFeature returns the nucleotide sequence covered by a feature, joining its spans in order
+// and reverse complementing any span on the complement strand.
+func ExtractFeature(sequence string, f Feature) (string, error) {
+	loc, err := f.ParseLocation()
+	if err != nil {
+		return "", err
+	}
+	seq, err := loc.Extract(sequence)
+	if err != nil {
+		return "", fmt.Errorf("feature %s %s: %v", f.Key, f.Location, err)
+	}
+	return seq, nil
+}
+
+// FeatureSequence extracts the sequence of a feature belonging to the EMBLEntry.
+func (e *EMBLEntry) FeatureSequence(f Feature) (string, error) {
+	return ExtractFeature(e.Sequence, f)
+}
+
+// FeatureSequence extracts the sequence of a feature belonging to the GenBankEntry.
+func (e *GenBankEntry) FeatureSequence(f GenBankFeature) (string, error) {
+	return ExtractFeature(e.Sequence, f)
+}
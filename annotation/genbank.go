@@ -2,6 +2,8 @@ package annotation
 
 import (
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	"gopher-proteinlab/parseio"
@@ -9,177 +11,474 @@ import (
 
 // GenBankEntry represents a parsed GenBank file entry.
 type GenBankEntry struct {
-	Locus      string             // Locus line containing information about the sequence
-	Definition string             // Definition line describing the sequence
-	Accession  []string           // Accession numbers of the sequence
-	Version    string             // Version information of the sequence
-	Keywords   []string           // Keywords associated with the sequence
-	Source     string             // Source organism or cell line for the sequence
-	Organism   string             // Full organism classification of the sequence
-	References []GenBankReference // List of references in the sequence
-	Features   []GenBankFeature   // List of features such as genes and coding sequences
-	Sequence   string             // The nucleotide or protein sequence
+	Locus        string             // Locus name from the LOCUS line
+	Length       int                // Length of the sequence in bases or residues
+	MoleculeType string             // Molecule type from the LOCUS line (e.g., DNA, mRNA)
+	Topology     string             // linear or circular
+	Division     string             // GenBank division (e.g., PRI, CON)
+	Date         string             // Modification date from the LOCUS line
+	Definition   string             // Definition line describing the sequence
+	Accession    []string           // Accession numbers of the sequence
+	Version      string             // Version information of the sequence
+	DBLink       []string           // Links to other databases such as BioProject
+	DBSource     string             // Source database of a protein record, lines separated by newlines
+	Keywords     []string           // Keywords associated with the sequence
+	Source       string             // Source organism or cell line for the sequence
+	Organism     string             // Scientific name of the source organism
+	Taxonomy     []string           // Taxonomic lineage of the source organism
+	References   []GenBankReference // List of references in the sequence
+	Comment      string             // Free text comment, paragraphs separated by newlines
+	Primary      string             // PRIMARY table of a RefSeq record
+	Features     []GenBankFeature   // List of features such as genes and coding sequences
+	Contig       string             // CONTIG construction of a CON division record
+	Sequence     string             // The nucleotide or protein sequence
 }
 
-// GenBankFeature represents a feature in a GenBank file.
-type GenBankFeature struct {
-	Key        string            // Feature key (e.g., CDS, gene)
-	Location   string            // Location of the feature in the sequence
-	Qualifiers map[string]string // Additional qualifiers for the feature
-}
+// GenBankFeature represents a feature in a GenBank file. GenBank and EMBL share the
+// INSDC feature table, so it is the same type as Feature.
+type GenBankFeature = Feature
 
 // GenBankReference represents a reference section in a GenBank file.
 type GenBankReference struct {
-	Number  string // Reference number
-	Authors string // Authors of the reference
-	Title   string // Title of the referenced work
-	Journal string // Journal of publication
-	Medline string // Medline information
-	Comment string // Additional comments about the reference
+	Number     string // Reference number
+	Bases      string // Bases the reference applies to (e.g., bases 1 to 655)
+	Authors    string // Authors of the reference
+	Consortium string // Consortium of the reference
+	Title      string // Title of the referenced work
+	Journal    string // Journal of publication
+	Medline    string // Medline information
+	PubMed     string // PubMed identifier
+	Remark     string // Additional remarks about the reference
 }
 
-// GenBankReader reads a GenBank file, parses its content, and returns the parsed entry data.
-// It processes the file in chunks to avoid loading the entire file into memory.
-func parseGenBank(scanner *parseio.Scanalyzer) (*GenBankEntry, error) {
-	entry := &GenBankEntry{}
+// genBankKeywordWidth is the column where values start on GenBank header lines.
+const genBankKeywordWidth = 12
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// GenBankReader streams the records of a plain or gzipped GenBank (.gbff) file one at a time.
+type GenBankReader struct {
+	flatFileReader
+}
 
-		// Use switch case to handle different line types in the GenBank file
-		switch {
-		case strings.HasPrefix(line, "LOCUS"):
-			entry.Locus = strings.Fields(line[12:])[0] // Capture only the first word
-		case strings.HasPrefix(line, "DEFINITION"):
-			entry.Definition = strings.TrimSpace(line[12:])
-		case strings.HasPrefix(line, "ACCESSION"):
-			entry.Accession = strings.Fields(line[12:])
-		case strings.HasPrefix(line, "VERSION"):
-			entry.Version = strings.TrimSpace(line[12:])
-		case strings.HasPrefix(line, "KEYWORDS"):
-			entry.Keywords = strings.Split(strings.TrimSpace(line[12:]), ";")
-		case strings.HasPrefix(line, "SOURCE"):
-			entry.Source = strings.TrimSpace(line[12:])
-		case strings.HasPrefix(line, "ORGANISM"):
-			entry.Organism = readMultiLineOrganism(scanner, line)
-		case strings.HasPrefix(line, "REFERENCE"):
-			entry.References = append(entry.References, readReference(scanner, line))
-		case strings.HasPrefix(line, "FEATURES"):
-			entry.Features = readFeatures(scanner)
-		case strings.HasPrefix(line, "ORIGIN"):
-			entry.Sequence = readSequence(scanner)
-		}
+// genBankKeywords lists the keywords of GenBank header lines. Keywords that are not stored in a
+// GenBankEntry are skipped together with their continuation lines.
+var genBankKeywords = map[string]bool{
+	"LOCUS": true, "DEFINITION": true, "ACCESSION": true, "VERSION": true, "DBLINK": true,
+	"DBSOURCE": true, "KEYWORDS": true, "SEGMENT": true, "SOURCE": true, "ORGANISM": true,
+	"REFERENCE": true, "COMMENT": true, "PRIMARY": true, "FEATURES": true, "BASE COUNT": true,
+	"CONTIG": true, "ORIGIN": true, "PROJECT": true, "NID": true, "PID": true, "WGS": true,
+	"WGS_SCAFLD": true, "WGS_CONTIG": true, "TSA": true, "TLS": true, "//": true,
+}
+
+// RecordLines holds the line numbers where a record read from a flat file starts, where each of
+// its features starts and where its sequence starts, so that problems can be traced to the file.
+type RecordLines struct {
+	Record   int   // LOCUS or ID line
+	Features []int // Line of each feature key, in the order of the features
+	Sequence int   // ORIGIN or SQ line, 0 when the record has no sequence
+}
+
+// Feature returns the line of the i-th feature, or the line of the record when it is unknown.
+func (l RecordLines) Feature(i int) int {
+	if i < len(l.Features) {
+		return l.Features[i]
 	}
+	return l.Record
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %v", err)
+// NewGenBankReader opens a GenBank file for reading.
+func NewGenBankReader(filename string) *GenBankReader {
+	return &GenBankReader{flatFileReader{
+		filename: filename,
+		scanner:  parseio.NewScanner(filename),
+	}}
+}
+
+// Read returns the next record of the file, or io.EOF once every record has been read.
+// Malformed records are returned as a *ParseError in strict mode and skipped in lenient mode.
+func (r *GenBankReader) Read() (*GenBankEntry, error) {
+	for {
+		r.lines = RecordLines{}
+		entry, err := parseGenBank(r.scanner, &r.lines)
+		if err == nil || err == io.EOF {
+			return entry, err
+		}
+		if skipped, err := r.recover(err, "LOCUS"); !skipped {
+			return nil, err
+		}
 	}
+}
 
-	return entry, nil
+// ParseGenBank parses one GenBank record at a time from the provided scanner, returning io.EOF
+// once there are no more records. It processes the file line by line to avoid loading the entire
+// file into memory. Malformed input is reported as a *ParseError.
+func ParseGenBank(scanner *parseio.Scanalyzer) (*GenBankEntry, error) {
+	return parseGenBank(scanner, &RecordLines{})
 }
 
-// readMultiLineOrganism reads multi-line organism information from the GenBank file.
-func readMultiLineOrganism(scanner *parseio.Scanalyzer, line string) string {
-	// Start with the first line of the organism
-	organism := strings.TrimSpace(line[12:])
-	for scanner.Scan() {
-		nextLine := strings.TrimSpace(scanner.Text())
-		if len(nextLine) == 0 || strings.HasPrefix(nextLine, "REFERENCE") || strings.HasPrefix(nextLine, "FEATURES") {
-			break
+// parseGenBank parses the next GenBank record and stores the line numbers of its parts in lines.
+func parseGenBank(scanner *parseio.Scanalyzer, lines *RecordLines) (*GenBankEntry, error) {
+	var entry *GenBankEntry
+	fail := func(err error) (*GenBankEntry, error) {
+		var locus string
+		if entry != nil {
+			locus = entry.Locus
 		}
-		organism += " " + nextLine
+		return nil, newParseError(scanner, locus, err)
 	}
-	return organism
+
+	for next, ok := scanner.Peek(); ok; next, ok = scanner.Peek() {
+		if entry != nil && strings.HasPrefix(next, "LOCUS") {
+			return nil, newTruncatedError(scanner, entry.Locus, next)
+		}
+		scanner.Scan()
+		line := scanner.Text()
+		if entry == nil {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if !strings.HasPrefix(line, "LOCUS") {
+				return fail(fmt.Errorf("expected LOCUS line"))
+			}
+			entry = &GenBankEntry{}
+		}
+
+		// Use switch case to handle the keyword in the first 12 columns of the line
+		keyword, value := splitKeyword(line)
+		if keyword != "" && !isContinuation(line) && !genBankKeywords[keyword] {
+			return fail(fmt.Errorf("unknown keyword %s", keyword))
+		}
+		switch keyword {
+		case "LOCUS":
+			lines.Record = scanner.Line()
+			if err := parseLocus(entry, value); err != nil {
+				return fail(err)
+			}
+		case "DEFINITION":
+			entry.Definition = readContinuation(scanner, value, " ")
+		case "ACCESSION":
+			entry.Accession = strings.Fields(readContinuation(scanner, value, " "))
+		case "VERSION":
+			entry.Version = value
+		case "DBLINK":
+			entry.DBLink = readLines(scanner, value)
+		case "DBSOURCE":
+			entry.DBSource = strings.Join(readLines(scanner, value), "\n")
+		case "KEYWORDS":
+			entry.Keywords = splitKeywords(readContinuation(scanner, value, " "))
+		case "SOURCE":
+			entry.Source = readContinuation(scanner, value, " ")
+		case "ORGANISM":
+			entry.Organism, entry.Taxonomy = readOrganism(scanner, value)
+		case "REFERENCE":
+			entry.References = append(entry.References, readReference(scanner, value))
+		case "COMMENT":
+			entry.Comment = strings.Join(readLines(scanner, value), "\n")
+		case "PRIMARY":
+			entry.Primary = strings.Join(readLines(scanner, value), "\n")
+		case "FEATURES":
+			features, err := readFeatures(scanner, "     ", lines)
+			if err != nil {
+				return fail(err)
+			}
+			entry.Features = features
+		case "CONTIG":
+			entry.Contig = readContinuation(scanner, value, "")
+		case "ORIGIN":
+			lines.Sequence = scanner.Line()
+			sequence, err := readSequence(scanner)
+			if err != nil {
+				return fail(err)
+			}
+			entry.Sequence = sequence
+		case "//":
+			return entry, nil
+		}
+	}
+
+	if entry == nil {
+		return nil, endOfInput(scanner, false, "")
+	}
+	return nil, endOfInput(scanner, true, entry.Locus)
 }
 
-// readReference reads a reference section from the GenBank file and returns a GenBankReference.
-func readReference(scanner *parseio.Scanalyzer, firstLine string) GenBankReference {
-	var reference GenBankReference
-	reference.Number = strings.TrimSpace(firstLine[12:])
+// splitKeyword splits a header line into the keyword held in the first 12 columns and its value.
+func splitKeyword(line string) (string, string) {
+	if len(line) <= genBankKeywordWidth {
+		return strings.TrimSpace(line), ""
+	}
+	return strings.TrimSpace(line[:genBankKeywordWidth]), strings.TrimSpace(line[genBankKeywordWidth:])
+}
+
+// isContinuation reports whether a header line continues the value of the previous keyword.
+func isContinuation(line string) bool {
+	return len(line) >= genBankKeywordWidth && strings.TrimSpace(line[:genBankKeywordWidth]) == ""
+}
+
+// readLines returns the first value and every continuation line that follows it.
+func readLines(scanner *parseio.Scanalyzer, first string) []string {
+	lines := []string{first}
+	for next, ok := scanner.Peek(); ok && isContinuation(next); next, ok = scanner.Peek() {
+		scanner.Scan()
+		lines = append(lines, strings.TrimSpace(next))
+	}
+	return lines
+}
 
-	// Continue reading the reference block
-	for scanner.Scan() {
-		refLine := strings.TrimSpace(scanner.Text())
+// readContinuation reads a multi-line value and joins its lines with sep.
+func readContinuation(scanner *parseio.Scanalyzer, first string, sep string) string {
+	return strings.Join(readLines(scanner, first), sep)
+}
 
+// parseLocus reads the name, length, molecule type, topology, division and date of a LOCUS line.
+func parseLocus(entry *GenBankEntry, value string) error {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return fmt.Errorf("LOCUS line has no locus name")
+	}
+	entry.Locus = fields[0]
+	fields = fields[1:]
+	if len(fields) >= 2 && (fields[1] == "bp" || fields[1] == "aa") {
+		length, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid LOCUS length %q", fields[0])
+		}
+		entry.Length = length
+		if fields[1] == "aa" {
+			entry.MoleculeType = "aa"
+		}
+		fields = fields[2:]
+	}
+	if n := len(fields); n > 0 && strings.Count(fields[n-1], "-") == 2 {
+		entry.Date = fields[n-1]
+		fields = fields[:n-1]
+	}
+	for _, field := range fields {
 		switch {
-		case strings.HasPrefix(refLine, "AUTHORS"):
-			reference.Authors = strings.TrimSpace(refLine[12:])
-		case strings.HasPrefix(refLine, "TITLE"):
-			reference.Title = strings.TrimSpace(refLine[12:])
-		case strings.HasPrefix(refLine, "JOURNAL"):
-			reference.Journal = strings.TrimSpace(refLine[12:])
-		case strings.HasPrefix(refLine, "MEDLINE"):
-			reference.Medline = strings.TrimSpace(refLine[12:])
-		case strings.HasPrefix(refLine, "COMMENT"):
-			reference.Comment = strings.TrimSpace(refLine[12:])
+		case field == "linear" || field == "circular":
+			entry.Topology = field
+		case len(field) == 3 && strings.ToUpper(field) == field && entry.MoleculeType != "":
+			entry.Division = field
 		default:
-			// Stop reading once we reach a non-reference line
-			return reference
+			entry.MoleculeType = field
 		}
 	}
-	return reference
+	return nil
+}
+
+// splitKeywords splits the KEYWORDS value on semicolons, dropping the final period.
+func splitKeywords(value string) []string {
+	value = strings.TrimSuffix(strings.TrimSpace(value), ".")
+	if value == "" {
+		return nil
+	}
+	keywords := strings.Split(value, ";")
+	for i := range keywords {
+		keywords[i] = strings.TrimSpace(keywords[i])
+	}
+	return keywords
 }
 
-// readFeatures reads the features section from the GenBank file and returns a slice of GenBankFeatures.
-func readFeatures(scanner *parseio.Scanalyzer) []GenBankFeature {
-	var features []GenBankFeature
-	var currentFeature GenBankFeature
+// readOrganism reads the organism name and the taxonomic lineage on the lines below it.
+func readOrganism(scanner *parseio.Scanalyzer, first string) (string, []string) {
+	name := first
+	lines := readLines(scanner, first)[1:]
+	for len(lines) > 0 && !strings.Contains(lines[0], ";") && !strings.HasSuffix(lines[0], ".") {
+		name += " " + lines[0] // Organism names long enough to wrap
+		lines = lines[1:]
+	}
+	var lineage []string
+	for _, taxon := range strings.Split(strings.TrimSuffix(strings.Join(lines, " "), "."), ";") {
+		if taxon = strings.TrimSpace(taxon); taxon != "" {
+			lineage = append(lineage, taxon)
+		}
+	}
+	return name, lineage
+}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
+// readReference reads a reference section from the GenBank file and returns a GenBankReference.
+// It stops before the first line that does not belong to the reference.
+func readReference(scanner *parseio.Scanalyzer, first string) GenBankReference {
+	reference := GenBankReference{Number: first}
+	if fields := strings.SplitN(first, " ", 2); len(fields) == 2 {
+		reference.Number = fields[0]
+		reference.Bases = strings.Trim(strings.TrimSpace(fields[1]), "()")
+	}
 
-		// Stop processing features when ORIGIN (sequence) is reached
-		if strings.HasPrefix(line, "ORIGIN") {
-			break
+	// Continue reading the sub-keywords of the reference block
+	for next, ok := scanner.Peek(); ok && strings.HasPrefix(next, " ") && !isContinuation(next); next, ok = scanner.Peek() {
+		keyword, value := splitKeyword(next)
+		var field *string
+		switch keyword {
+		case "AUTHORS":
+			field = &reference.Authors
+		case "CONSRTM":
+			field = &reference.Consortium
+		case "TITLE":
+			field = &reference.Title
+		case "JOURNAL":
+			field = &reference.Journal
+		case "MEDLINE":
+			field = &reference.Medline
+		case "PUBMED":
+			field = &reference.PubMed
+		case "REMARK":
+			field = &reference.Remark
+		default:
+			return reference
 		}
+		scanner.Scan()
+		*field = readContinuation(scanner, value, " ")
+	}
+	return reference
+}
 
-		// Handle feature lines (starting with feature key, like CDS)
-		if strings.HasPrefix(line, "     ") && !strings.HasPrefix(line, "/") {
-			// Append the current feature if it exists
-			if currentFeature.Key != "" {
-				features = append(features, currentFeature)
-			}
+// readFeatures reads a feature table whose lines start with prefix and returns the features.
+// Feature keys start right after the prefix and locations and qualifiers start 16 columns later.
+// The line of each feature key is appended to lines.Features.
+func readFeatures(scanner *parseio.Scanalyzer, prefix string, lines *RecordLines) ([]Feature, error) {
+	var features []Feature
+	var table featureTable
 
-			// Create a new feature
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				currentFeature = GenBankFeature{
-					Key:        parts[0],
-					Location:   parts[1],
-					Qualifiers: make(map[string]string),
-				}
+	for next, ok := scanner.Peek(); ok && strings.HasPrefix(next, prefix); next, ok = scanner.Peek() {
+		scanner.Scan()
+		line := next[len(prefix):]
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] != ' ' {
+			// Append the current feature if it exists and start a new one
+			if feature, ok, err := table.finish(); err != nil {
+				return nil, err
+			} else if ok {
+				features = append(features, feature)
 			}
-		} else if strings.HasPrefix(line, "/") { // Handle qualifier lines (e.g., /gene="sod")
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) == 2 {
-				qualifierKey := strings.TrimSpace(parts[0])
-				qualifierValue := strings.Trim(strings.TrimSpace(parts[1]), "\"")
-				currentFeature.Qualifiers[qualifierKey] = qualifierValue
+			lines.Features = append(lines.Features, scanner.Line())
+			fields := strings.Fields(line)
+			if len(fields) == 1 {
+				return nil, fmt.Errorf("feature %s has no location", fields[0])
 			}
+			table.start(fields[0], strings.Join(fields[1:], ""))
+			continue
+		}
+		if err := table.add(strings.TrimSpace(line)); err != nil {
+			return nil, err
 		}
 	}
 
 	// Append the last feature if present
-	if currentFeature.Key != "" {
-		features = append(features, currentFeature)
+	feature, ok, err := table.finish()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		features = append(features, feature)
 	}
+	return features, nil
+}
+
+// featureTable accumulates the location and qualifier lines of the feature being read.
+type featureTable struct {
+	feature   Feature
+	active    bool
+	qualifier string
+	value     []string
+}
 
-	return features
+// start begins a new feature.
+func (t *featureTable) start(key, location string) {
+	t.feature = Feature{Key: key, Location: location, Qualifiers: make(map[string]string)}
+	t.active = true
+	t.qualifier, t.value = "", nil
 }
 
-// readSequence reads the sequence data from the GenBank file.
-func readSequence(scanner *parseio.Scanalyzer) string {
+// add appends a line to the current qualifier, starts a new qualifier, or continues the location.
+func (t *featureTable) add(text string) error {
+	if !t.active {
+		return fmt.Errorf("qualifier or location line before the first feature key")
+	}
+	switch {
+	case t.open():
+		t.value = append(t.value, text)
+	case strings.HasPrefix(text, "/"):
+		t.flush()
+		key, value, _ := strings.Cut(text, "=")
+		t.qualifier, t.value = key, []string{value}
+	case t.qualifier == "":
+		t.feature.Location += text
+	default:
+		t.value = append(t.value, text)
+	}
+	return nil
+}
+
+// open reports whether the value of the current qualifier has an unterminated quote.
+func (t *featureTable) open() bool {
+	return strings.Count(strings.Join(t.value, ""), `"`)%2 == 1
+}
+
+// flush stores the current qualifier. Repeated qualifiers keep every value, one per line.
+func (t *featureTable) flush() {
+	if t.qualifier == "" {
+		return
+	}
+	sep := " "
+	if t.qualifier == "/translation" {
+		sep = ""
+	}
+	value := strings.Join(t.value, sep)
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = strings.ReplaceAll(value[1:len(value)-1], `""`, `"`)
+	}
+	if previous, ok := t.feature.Qualifiers[t.qualifier]; ok {
+		value = previous + "\n" + value
+	}
+	t.feature.Qualifiers[t.qualifier] = value
+	t.qualifier, t.value = "", nil
+}
+
+// finish returns the feature being read, if any, or an error when its last qualifier value is
+// missing its closing quote.
+func (t *featureTable) finish() (Feature, bool, error) {
+	if !t.active {
+		return Feature{}, false, nil
+	}
+	if t.open() {
+		return Feature{}, false, fmt.Errorf("value of qualifier %s of feature %s has no closing quote", t.qualifier, t.feature.Key)
+	}
+	t.flush()
+	t.active = false
+	return t.feature, true, nil
+}
+
+// readSequence reads the sequence data of the ORIGIN section up to the end of the record.
+func readSequence(scanner *parseio.Scanalyzer) (string, error) {
 	var sequenceBuilder strings.Builder
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "//" {
-			break
-		}
+	for next, ok := scanner.Peek(); ok && strings.HasPrefix(next, " "); next, ok = scanner.Peek() {
+		scanner.Scan()
 		// Remove line numbers and spaces from the sequence
-		sequenceParts := strings.Fields(line)
-		if len(sequenceParts) > 1 {
-			sequenceBuilder.WriteString(strings.Join(sequenceParts[1:], ""))
+		sequenceParts := strings.Fields(next)
+		if len(sequenceParts) == 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(sequenceParts[0]); err != nil {
+			return "", fmt.Errorf("sequence line does not start with a position")
+		}
+		for _, part := range sequenceParts[1:] {
+			if !isLetters(part) {
+				return "", fmt.Errorf("invalid sequence %q", part)
+			}
+			sequenceBuilder.WriteString(part)
+		}
+	}
+	return sequenceBuilder.String(), nil
+}
+
+// isLetters reports whether text only holds ASCII letters, or the * and - of protein sequences.
+func isLetters(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if c := text[i] | 0x20; (c < 'a' || c > 'z') && text[i] != '*' && text[i] != '-' {
+			return false
 		}
 	}
-	return sequenceBuilder.String()
+	return true
 }
@@ -1,68 +1,187 @@
 package annotation
 
-// import (
-// 	"bufio"
-// 	"gopher-proteinlab/parseio"
-// 	"reflect"
-// 	"strings"
-// 	"testing"
-// )
+import (
+	"bufio"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
 
-// func TestParseGenBank(t *testing.T) {
-// 	// Simulate a small portion of a GenBank entry
-// 	genbankData := `
-// LOCUS       LISOD                    756 bp    DNA     linear   BCT 30-JUN-1993
-// DEFINITION  Listeria ivanovii sod gene for superoxide dismutase.
-// ACCESSION   X64011 S78972
-// VERSION     X64011.1  GI:44010
-// KEYWORDS    sod gene; superoxide dismutase.
-// SOURCE      Listeria ivanovii
-// 	ORGANISM  Listeria ivanovii
-//             Bacteria; Firmicutes; Bacillales; Listeriaceae; Listeria.
-// FEATURES             Location/Qualifiers
-// 	CDS             109..717
-//                     /gene="sod"
-//                     /product="superoxide dismutase"
-// ORIGIN
-//         1 cgttatttaa ggtgttacat agttctatgg aaatagggtc tatacctttc gccttacaat
-//        61 gtaatttctt ..........
-// //
-// `
+	"gopher-proteinlab/parseio"
+)
 
-// 	// Create a scanner for the test data
-// 	genbankReader := strings.NewReader(genbankData)
-// 	genbankScanner := &parseio.Scanalyzer{
-// 		Scanner: bufio.NewScanner(genbankReader), // Wrap the bufio.Scanner
-// 	}
+func TestParseGenBank(t *testing.T) {
+	// Simulate a small portion of a GenBank entry
+	genbankData := `
+LOCUS       LISOD                    756 bp    DNA     linear   BCT 30-JUN-1993
+DEFINITION  Listeria ivanovii sod gene for superoxide dismutase.
+ACCESSION   X64011 S78972
+VERSION     X64011.1  GI:44010
+KEYWORDS    sod gene; superoxide dismutase.
+SOURCE      Listeria ivanovii
+  ORGANISM  Listeria ivanovii
+            Bacteria; Firmicutes; Bacillales; Listeriaceae; Listeria.
+REFERENCE   1  (bases 1 to 756)
+  AUTHORS   Haas,A. and Goebel,W.
+  TITLE     Cloning of a superoxide dismutase gene from Listeria ivanovii by
+            functional complementation in Escherichia coli
+  JOURNAL   Mol. Gen. Genet. 231 (2), 313-322 (1992)
+   PUBMED   1736100
+FEATURES             Location/Qualifiers
+     CDS             109..717
+                     /gene="sod"
+                     /product="superoxide dismutase"
+                     /db_xref="GI:44011"
+                     /db_xref="SWISS-PROT:P28763"
+                     /translation="MTYELPKLPYTYDALEPNFDKETMEIHYTKHHNIYVTKLNEAVS
+                     GHAELASKPGEELVANLDSVPEEIRGAVRNHGGGHANHTLFWSSLSPNGGGAPTGNLK
+                     AAIESEFGTFDEFKEKFNAAAAARFGSGWAWLVVNNGKLEIVSTANQDSPLSEGKTPV
+                     LGLDVWEHAYYLKFQNRRPEYIDTFWNVINWDERNKRFDAAK"
+ORIGIN
+        1 cgttatttaa ggtgttacat agttctatgg aaatagggtc tatacctttc gccttacaat
+       61 gtaatttctt
+//
+`
 
-// 	// Parse the GenBank entry
-// 	entry, err := parseGenBank(genbankScanner)
-// 	if err != nil {
-// 		t.Fatalf("parseGenBank failed: %v", err)
-// 	}
+	// Create a scanner for the test data
+	genbankReader := strings.NewReader(genbankData)
+	genbankScanner := &parseio.Scanalyzer{
+		Scanner: bufio.NewScanner(genbankReader), // Wrap the bufio.Scanner
+	}
 
-// 	expectedEntry := &GenBankEntry{
-// 		Locus:      "LISOD",
-// 		Definition: "Listeria ivanovii sod gene for superoxide dismutase.",
-// 		Accession:  []string{"X64011", "S78972"},
-// 		Version:    "X64011.1  GI:44010",
-// 		Keywords:   []string{"sod gene", "superoxide dismutase"},
-// 		Source:     "Listeria ivanovii",
-// 		Organism:   "Listeria ivanovii Bacteria; Firmicutes; Bacillales; Listeriaceae; Listeria.",
-// 		Features: []GenBankFeature{
-// 			{
-// 				Key:      "CDS",
-// 				Location: "109..717",
-// 				Qualifiers: map[string]string{
-// 					"/gene":    "sod",
-// 					"/product": "superoxide dismutase",
-// 				},
-// 			},
-// 		},
-// 		Sequence: "cgttatttaaggtgttacatagttctatggaaatagggtctatacctttcgccttacaatgtaatttctt..........",
-// 	}
+	// Parse the GenBank entry
+	entry, err := ParseGenBank(genbankScanner)
+	if err != nil {
+		t.Fatalf("ParseGenBank failed: %v", err)
+	}
 
-// 	if !reflect.DeepEqual(entry, expectedEntry) {
-// 		t.Errorf("Parsed entry does not match expected entry.\nParsed: %s\nExpected: %s", entry.ToJson(), expectedEntry.ToJson())
-// 	}
-// }
+	expectedEntry := &GenBankEntry{
+		Locus:        "LISOD",
+		Length:       756,
+		MoleculeType: "DNA",
+		Topology:     "linear",
+		Division:     "BCT",
+		Date:         "30-JUN-1993",
+		Definition:   "Listeria ivanovii sod gene for superoxide dismutase.",
+		Accession:    []string{"X64011", "S78972"},
+		Version:      "X64011.1  GI:44010",
+		Keywords:     []string{"sod gene", "superoxide dismutase"},
+		Source:       "Listeria ivanovii",
+		Organism:     "Listeria ivanovii",
+		Taxonomy:     []string{"Bacteria", "Firmicutes", "Bacillales", "Listeriaceae", "Listeria"},
+		References: []GenBankReference{
+			{
+				Number:  "1",
+				Bases:   "bases 1 to 756",
+				Authors: "Haas,A. and Goebel,W.",
+				Title:   "Cloning of a superoxide dismutase gene from Listeria ivanovii by functional complementation in Escherichia coli",
+				Journal: "Mol. Gen. Genet. 231 (2), 313-322 (1992)",
+				PubMed:  "1736100",
+			},
+		},
+		Features: []GenBankFeature{
+			{
+				Key:      "CDS",
+				Location: "109..717",
+				Qualifiers: map[string]string{
+					"/gene":        "sod",
+					"/product":     "superoxide dismutase",
+					"/db_xref":     "GI:44011\nSWISS-PROT:P28763",
+					"/translation": "MTYELPKLPYTYDALEPNFDKETMEIHYTKHHNIYVTKLNEAVSGHAELASKPGEELVANLDSVPEEIRGAVRNHGGGHANHTLFWSSLSPNGGGAPTGNLKAAIESEFGTFDEFKEKFNAAAAARFGSGWAWLVVNNGKLEIVSTANQDSPLSEGKTPVLGLDVWEHAYYLKFQNRRPEYIDTFWNVINWDERNKRFDAAK",
+				},
+			},
+		},
+		Sequence: "cgttatttaaggtgttacatagttctatggaaatagggtctatacctttcgccttacaatgtaatttctt",
+	}
+
+	if !reflect.DeepEqual(entry, expectedEntry) {
+		t.Errorf("Parsed entry does not match expected entry.\nParsed: %s\nExpected: %s", entry.ToJson(), expectedEntry.ToJson())
+	}
+
+	if _, err = ParseGenBank(genbankScanner); err != io.EOF {
+		t.Errorf("Error: expected io.EOF after the last record, got %v", err)
+	}
+}
+
+func TestGenBankReader(t *testing.T) {
+	reader := NewGenBankReader("testdata/human.biological_region.gbff.gz")
+	defer reader.Close()
+
+	var entries []*GenBankEntry
+	for {
+		entry, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed after %d records: %v", len(entries), err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 5810 {
+		t.Fatalf("Error: expected 5810 records, got %d", len(entries))
+	}
+
+	first := entries[0]
+	if first.Locus != "NG_055818" || first.Length != 655 || first.Division != "CON" || first.Date != "24-SEP-2024" {
+		t.Errorf("Error: LOCUS line parsed as %s %d %s %s", first.Locus, first.Length, first.Division, first.Date)
+	}
+	if first.Definition != "Homo sapiens CCNC and PRDM13 intergenic region DNase I hypersensitve site DHS6S1 (LOC111365204) on chromosome 6." {
+		t.Errorf("Error: multi-line DEFINITION parsed as %q", first.Definition)
+	}
+	if !reflect.DeepEqual(first.DBLink, []string{"BioProject: PRJNA343958"}) || !reflect.DeepEqual(first.Keywords, []string{"RefSeq", "RefSeqFE"}) {
+		t.Errorf("Error: DBLINK %q or KEYWORDS %q parsed incorrectly", first.DBLink, first.Keywords)
+	}
+	if first.Organism != "Homo sapiens" || len(first.Taxonomy) != 14 || first.Taxonomy[13] != "Homo" {
+		t.Errorf("Error: ORGANISM parsed as %q %q", first.Organism, first.Taxonomy)
+	}
+	if len(first.References) != 10 {
+		t.Fatalf("Error: expected 10 references, got %d", len(first.References))
+	}
+	if ref := first.References[0]; ref.PubMed != "34158671" || ref.Bases != "bases 1 to 655" ||
+		ref.Remark != "GeneRIF: Modern diagnostic and therapeutic approaches in familial maculopathy with reference to North Carolina macular dystrophy." {
+		t.Errorf("Error: REFERENCE parsed as %+v", ref)
+	}
+	if ref := first.References[9]; ref.Number != "10" || ref.Title != "North Carolina macular dystrophy phenotype in France maps to the MCDR1 locus" {
+		t.Errorf("Error: REFERENCE parsed as %+v", ref)
+	}
+	if !strings.HasPrefix(first.Comment, "REVIEWED REFSEQ: This record has been curated by NCBI staff. The\nreference sequence") {
+		t.Errorf("Error: COMMENT parsed as %q", first.Comment)
+	}
+	if first.Contig != "join(AL137784.14:110302..110956)" {
+		t.Errorf("Error: CONTIG parsed as %q", first.Contig)
+	}
+	if len(first.Features) != 7 {
+		t.Fatalf("Error: expected 7 features, got %d", len(first.Features))
+	}
+	if feature := first.Features[2]; feature.Key != "regulatory" || feature.Location != "101..555" ||
+		feature.Qualifiers["/experiment"] != "EXISTENCE:in vivo cleavage assay evidence [ECO:0001075][PMID:22955617]" {
+		t.Errorf("Error: feature parsed as %+v", feature)
+	}
+	if feature := first.Features[3]; feature.Qualifiers["/db_xref"] != "dbSNP:rs1554264612\nGeneID:111365204" {
+		t.Errorf("Error: repeated qualifiers parsed as %q", feature.Qualifiers["/db_xref"])
+	}
+	if last := entries[len(entries)-1]; last.Locus == "" || len(last.Features) == 0 || last.Contig == "" {
+		t.Errorf("Error: last record parsed as %s", last.ToString())
+	}
+}
+
+func TestGenBankReaderError(t *testing.T) {
+	if tmpfile, err := os.CreateTemp("", "*.gbff"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.WriteString("LOCUS       A 10 bp DNA linear PRI 01-JAN-2000\n//\nDEFINITION  no locus line\n//\n")
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+
+		reader := NewGenBankReader(tmpfile.Name())
+		defer reader.Close()
+		if entry, err := reader.Read(); err != nil || entry.Locus != "A" || entry.Length != 10 {
+			t.Errorf("Error: Read() = %+v, %v", entry, err)
+		}
+		_, err = reader.Read()
+		if expected := tmpfile.Name() + ":3: expected LOCUS line"; err == nil || !strings.HasPrefix(err.Error(), expected) {
+			t.Errorf("Error: Read() error = %v, expected: %s", err, expected)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package annotation
+
+import (
+	"fmt"
+
+	"gopher-proteinlab/protein"
+)
+
+// IsProtein reports whether the entry is a GenPept protein record, whose LOCUS line gives the
+// length in amino acids rather than base pairs.
+func (e *GenBankEntry) IsProtein() bool {
+	return e.MoleculeType == "aa"
+}
+
+// Proteins converts the sequence of a GenPept record to protein amino acids.
+func (e *GenBankEntry) Proteins() ([]protein.Protein, error) {
+	if !e.IsProtein() {
+		return nil, fmt.Errorf("%s is a %s record, not a protein record", e.Locus, e.MoleculeType)
+	}
+	proteins, err := protein.Encode(e.Sequence, protein.EncodeOptions{FoldCase: true})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", e.Locus, err)
+	}
+	return proteins, nil
+}
+
+// CodedBy parses the /coded_by qualifier of a GenPept CDS feature, which locates the coding
+// sequence on the nucleotide record the protein was translated from.
+func (f Feature) CodedBy() (Location, error) {
+	value, ok := f.Qualifiers["/coded_by"]
+	if !ok {
+		return Location{}, fmt.Errorf("feature %s %s has no /coded_by qualifier", f.Key, f.Location)
+	}
+	return ParseLocation(value)
+}
@@ -0,0 +1,75 @@
+package annotation
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+	"gopher-proteinlab/protein"
+)
+
+func TestParseGenPept(t *testing.T) {
+	genpeptData := `LOCUS       NP_000509                 20 aa            linear   PRI 15-OCT-2024
+DEFINITION  hemoglobin subunit beta [Homo sapiens].
+ACCESSION   NP_000509
+VERSION     NP_000509.1
+DBSOURCE    REFSEQ: accession NM_000518.5
+            UniProtKB: locus HBB_HUMAN, accession P68871;
+KEYWORDS    RefSeq; MANE Select.
+SOURCE      Homo sapiens (human)
+  ORGANISM  Homo sapiens
+            Eukaryota; Metazoa; Chordata; Craniata; Vertebrata; Euteleostomi;
+            Mammalia; Eutheria; Euarchontoglires; Primates; Haplorrhini;
+            Catarrhini; Hominidae; Homo.
+FEATURES             Location/Qualifiers
+     source          1..20
+                     /organism="Homo sapiens"
+                     /db_xref="taxon:9606"
+     Protein         1..20
+                     /product="hemoglobin subunit beta"
+                     /calculated_mol_wt=15867
+     Region          3..20
+                     /region_name="Globin"
+     Site            order(3,9..10)
+                     /site_type="other"
+     CDS             1..20
+                     /gene="HBB"
+                     /coded_by="NM_000518.5:51..
+                     494"
+ORIGIN
+        1 mvhltpeeks avtalwgkvn
+//
+`
+	scanner := &parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(genpeptData))}
+	entry, err := ParseGenBank(scanner)
+	if err != nil {
+		t.Fatalf("ParseGenBank failed: %v", err)
+	}
+	if !entry.IsProtein() || entry.Length != 20 || entry.Topology != "linear" || entry.Division != "PRI" {
+		t.Errorf("Error: LOCUS line parsed as %s %d %s %s %s", entry.Locus, entry.Length, entry.MoleculeType, entry.Topology, entry.Division)
+	}
+	if entry.DBSource != "REFSEQ: accession NM_000518.5\nUniProtKB: locus HBB_HUMAN, accession P68871;" {
+		t.Errorf("Error: DBSOURCE parsed as %q", entry.DBSource)
+	}
+	proteins, err := entry.Proteins()
+	if err != nil || protein.ToString(proteins) != "MVHLTPEEKSAVTALWGKVN" {
+		t.Errorf("Error: Proteins() = %s, %v", protein.ToString(proteins), err)
+	}
+
+	if len(entry.Features) != 5 {
+		t.Fatalf("Error: expected 5 features, got %d", len(entry.Features))
+	}
+	codedBy, err := entry.Features[4].CodedBy()
+	if err != nil || len(codedBy.Spans) != 1 || codedBy.Spans[0] != (Span{Start: 51, End: 494, Accession: "NM_000518.5"}) {
+		t.Errorf("Error: CodedBy() = %+v, %v", codedBy, err)
+	}
+	if _, err = entry.Features[0].CodedBy(); err == nil {
+		t.Errorf("Error: CodedBy() of a source feature expected an error")
+	}
+
+	nucleotide := &GenBankEntry{Locus: "X64011", MoleculeType: "DNA", Sequence: "acgt"}
+	if _, err = nucleotide.Proteins(); err == nil {
+		t.Errorf("Error: Proteins() of a DNA record expected an error")
+	}
+}
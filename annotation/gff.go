@@ -0,0 +1,404 @@
+package annotation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+)
+
+// GFFFeature is one line of a GFF3 file. Coordinates are 1-based and inclusive, Score and Phase
+// hold "." when they are not set and attributes may hold several values.
+type GFFFeature struct {
+	SeqID      string
+	Source     string
+	Type       string
+	Start      int
+	End        int
+	Score      string
+	Strand     byte // '+', '-', '.' or '?'
+	Phase      string
+	Attributes map[string][]string
+}
+
+// gffTypes maps INSDC feature keys to Sequence Ontology terms. Keys that are not listed are
+// written unchanged.
+var gffTypes = map[string]string{
+	"3'UTR": "three_prime_UTR", "5'UTR": "five_prime_UTR", "assembly_gap": "gap", "misc_difference": "sequence_difference",
+	"misc_feature": "sequence_feature", "misc_recomb": "recombination_feature", "misc_RNA": "transcript",
+	"mat_peptide": "mature_protein_region", "mobile_element": "mobile_genetic_element", "polyA_signal": "polyA_signal_sequence",
+	"precursor_RNA": "primary_transcript", "protein_bind": "protein_binding_site", "regulatory": "regulatory_region",
+	"rep_origin": "origin_of_replication", "sig_peptide": "signal_peptide", "source": "region", "variation": "sequence_alteration",
+}
+
+// gffTranscripts are the feature keys written as transcripts, the parents of CDS and exons.
+var gffTranscripts = map[string]bool{
+	"mRNA": true, "ncRNA": true, "rRNA": true, "tRNA": true, "tmRNA": true, "misc_RNA": true, "precursor_RNA": true,
+}
+
+// gffChildren are the feature keys that belong to a transcript.
+var gffChildren = map[string]bool{
+	"CDS": true, "exon": true, "intron": true, "5'UTR": true, "3'UTR": true,
+}
+
+// gffAttributeOrder lists the reserved GFF3 attributes in the order they are written, ahead of the
+// remaining attributes in alphabetical order.
+var gffAttributeOrder = []string{"ID", "Name", "Alias", "Parent", "Target", "Gap", "Derives_from", "Note", "Dbxref", "Ontology_term", "Is_circular"}
+
+// gffTranscript remembers a transcript so that its CDS and exons can refer to it.
+type gffTranscript struct {
+	id, gene   string
+	start, end int
+}
+
+// gffBuilder converts the features of one record, keeping the identifiers unique.
+type gffBuilder struct {
+	seqID, source string
+	protein       bool
+	used          map[string]bool
+	counts        map[string]int
+	genes         map[string]string
+	transcripts   []gffTranscript
+	lines         []GFFFeature
+}
+
+// GFFFeatures converts the features of a record to GFF3 lines. Locations are split into one line
+// per span, CDS lines get their phase from /codon_start and qualifiers become attributes, with
+// /note and /db_xref mapped to Note and Dbxref. Genes, transcripts and their CDS and exons are
+// linked through ID and Parent attributes, matched by /locus_tag or /gene. Features of protein
+// records such as UniProt entries keep their protein coordinates and have no strand.
+func GFFFeatures(record Record, source string) ([]GFFFeature, error) {
+	features, err := record.RecordFeatures()
+	if err != nil {
+		return nil, err
+	}
+	b := &gffBuilder{
+		seqID:  record.RecordID(),
+		source: source,
+		used:   make(map[string]bool),
+		counts: make(map[string]int),
+		genes:  make(map[string]string),
+	}
+	if accessions := record.RecordAccessions(); len(accessions) > 0 {
+		b.seqID = accessions[0]
+	}
+	b.protein = isProteinRecord(record)
+	for _, feature := range features {
+		b.add(feature)
+	}
+	return b.lines, nil
+}
+
+// isProteinRecord reports whether the features of a record are in protein coordinates, as they
+// are for UniProt entries and GenPept records.
+func isProteinRecord(record Record) bool {
+	if p, ok := record.(interface{ IsProtein() bool }); ok {
+		return p.IsProtein()
+	}
+	return false
+}
+
+// add converts one feature and appends its lines.
+func (b *gffBuilder) add(feature RecordFeature) {
+	var spans []Span
+	for _, span := range feature.Location.Spans {
+		if span.Accession == "" {
+			spans = append(spans, span)
+		}
+	}
+	if len(spans) == 0 {
+		return
+	}
+	loc := Location{Operator: feature.Location.Operator, Spans: spans}
+	start, end := loc.Start(), loc.End()
+	gene := firstQualifier(feature.Qualifiers, "/locus_tag", "/gene")
+
+	attributes := gffAttributes(feature.Qualifiers)
+	id := firstQualifier(feature.Qualifiers, "/id")
+	switch {
+	case id != "":
+		b.used[id] = true
+	case feature.Key == "gene":
+		id = b.uniqueID("gene", gene)
+		if gene != "" {
+			b.genes[gene] = id
+			attributes["Name"] = []string{firstQualifier(feature.Qualifiers, "/gene", "/locus_tag")}
+		}
+	case gffTranscripts[feature.Key]:
+		id = b.uniqueID("rna", firstQualifier(feature.Qualifiers, "/transcript_id", "/locus_tag", "/gene"))
+	case feature.Key == "CDS":
+		id = b.uniqueID("cds", firstQualifier(feature.Qualifiers, "/protein_id", "/locus_tag", "/gene"))
+	case len(spans) > 1:
+		id = b.uniqueID(strings.ToLower(feature.Key), "")
+	}
+	if id != "" {
+		attributes["ID"] = []string{id}
+	}
+
+	// Link transcripts to their gene and CDS and exons to the transcript that contains them
+	var parent string
+	if gffTranscripts[feature.Key] || gffChildren[feature.Key] {
+		parent = b.genes[gene]
+	}
+	if gffChildren[feature.Key] {
+		for i := len(b.transcripts) - 1; i >= 0; i-- {
+			if t := b.transcripts[i]; t.gene == gene && t.start <= start && end <= t.end {
+				parent = t.id
+				break
+			}
+		}
+	}
+	if gffTranscripts[feature.Key] && gene != "" {
+		b.transcripts = append(b.transcripts, gffTranscript{id: id, gene: gene, start: start, end: end})
+	}
+	if parent != "" {
+		attributes["Parent"] = []string{parent}
+	}
+
+	for _, span := range spans {
+		if span.PartialStart && span.Start == start {
+			attributes["partial"] = []string{"true"}
+			attributes["start_range"] = []string{".", strconv.Itoa(start)}
+		}
+		if span.PartialEnd && span.End == end {
+			attributes["partial"] = []string{"true"}
+			attributes["end_range"] = []string{strconv.Itoa(end), "."}
+		}
+	}
+
+	typ := feature.Key
+	if so, ok := gffTypes[typ]; ok {
+		typ = so
+	}
+	if typ == "regulatory_region" {
+		if class := firstQualifier(feature.Qualifiers, "/regulatory_class"); class != "" && class != "other" {
+			typ = class
+		}
+	}
+
+	// Spliced transcripts are written as one line spanning the exons, which become its children
+	if gffTranscripts[feature.Key] && len(spans) > 1 {
+		b.lines = append(b.lines, b.line(typ, Span{Start: start, End: end, Complement: spans[0].Complement}, ".", attributes))
+		for _, span := range spans {
+			b.lines = append(b.lines, b.line("exon", span, ".", map[string][]string{"Parent": {id}}))
+		}
+		return
+	}
+
+	codonStart, err := strconv.Atoi(firstQualifier(feature.Qualifiers, "/codon_start"))
+	if err != nil || codonStart < 1 || codonStart > 3 {
+		codonStart = 1
+	}
+	coded := -(codonStart - 1) // Bases of the CDS read before the current span, less the skipped bases
+	for _, span := range spans {
+		phase := "."
+		if feature.Key == "CDS" {
+			phase = strconv.Itoa(((-coded)%3 + 3) % 3)
+			coded += span.Len()
+		}
+		b.lines = append(b.lines, b.line(typ, span, phase, attributes))
+	}
+}
+
+// line returns a GFF3 line for one span of a feature.
+func (b *gffBuilder) line(typ string, span Span, phase string, attributes map[string][]string) GFFFeature {
+	strand := byte('+')
+	switch {
+	case b.protein:
+		strand = '.'
+	case span.Complement:
+		strand = '-'
+	}
+	end := span.End
+	if span.Between {
+		// GFF3 marks a site between two bases with a zero length feature to the right of Start
+		end = span.Start
+	}
+	return GFFFeature{
+		SeqID:      b.seqID,
+		Source:     b.source,
+		Type:       typ,
+		Start:      span.Start,
+		End:        end,
+		Score:      ".",
+		Strand:     strand,
+		Phase:      phase,
+		Attributes: attributes,
+	}
+}
+
+// uniqueID returns prefix-name, or prefix-n when there is no name, adding a number when the
+// identifier is already in use.
+func (b *gffBuilder) uniqueID(prefix, name string) string {
+	b.counts[prefix]++
+	if name == "" {
+		name = strconv.Itoa(b.counts[prefix])
+	}
+	id := prefix + "-" + name
+	for n := 2; b.used[id]; n++ {
+		id = fmt.Sprintf("%s-%s-%d", prefix, name, n)
+	}
+	b.used[id] = true
+	return id
+}
+
+// gffAttributes converts qualifiers to attributes. /translation is left out since the protein
+// sequence does not belong in GFF3, and /id is written as the ID attribute.
+func gffAttributes(qualifiers map[string]string) map[string][]string {
+	attributes := make(map[string][]string)
+	for key, value := range qualifiers {
+		values := strings.Split(value, "\n")
+		switch key {
+		case "/translation", "/id":
+			continue
+		case "/note":
+			attributes["Note"] = values
+		case "/db_xref":
+			attributes["Dbxref"] = values
+		default:
+			if value == "" {
+				values = []string{"true"}
+			}
+			attributes[strings.TrimPrefix(key, "/")] = values
+		}
+	}
+	return attributes
+}
+
+// firstQualifier returns the first value of the first qualifier in keys that is present.
+func firstQualifier(qualifiers map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := qualifiers[key]; ok && value != "" {
+			first, _, _ := strings.Cut(value, "\n")
+			return first
+		}
+	}
+	return ""
+}
+
+// ToString formats the feature as a GFF3 line without the trailing newline, percent-encoding the
+// characters GFF3 reserves.
+func (f GFFFeature) ToString() string {
+	txt := parseio.NewTxtBuilder()
+	score, phase, strand := f.Score, f.Phase, f.Strand
+	if score == "" {
+		score = "."
+	}
+	if phase == "" {
+		phase = "."
+	}
+	if strand == 0 {
+		strand = '.'
+	}
+	txt.WriteString(gffEscape(f.SeqID, true, ""))
+	txt.WriteByte('\t')
+	txt.WriteString(gffEscape(f.Source, false, ""))
+	txt.WriteByte('\t')
+	txt.WriteString(gffEscape(f.Type, false, ""))
+	txt.WriteString(fmt.Sprintf("\t%d\t%d\t%s\t%c\t%s\t", f.Start, f.End, score, strand, phase))
+
+	keys := make([]string, 0, len(f.Attributes))
+	for key := range f.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := gffAttributeRank(keys[i]), gffAttributeRank(keys[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) == 0 {
+		txt.WriteByte('.')
+	}
+	for i, key := range keys {
+		if i > 0 {
+			txt.WriteByte(';')
+		}
+		txt.WriteString(gffEscape(key, false, ";=&,"))
+		txt.WriteByte('=')
+		for j, value := range f.Attributes[key] {
+			if j > 0 {
+				txt.WriteByte(',')
+			}
+			txt.WriteString(gffEscape(value, false, ";=&,"))
+		}
+	}
+	return txt.String()
+}
+
+// gffAttributeRank returns the position of a reserved attribute, or one past the reserved
+// attributes for any other.
+func gffAttributeRank(key string) int {
+	for i, reserved := range gffAttributeOrder {
+		if key == reserved {
+			return i
+		}
+	}
+	return len(gffAttributeOrder)
+}
+
+// gffEscape percent-encodes %, tabs, newlines, control characters and the reserved characters.
+// Sequence IDs are further limited to letters, digits and .:^*$@!+_?-|.
+func gffEscape(text string, seqID bool, reserved string) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		escape := c < 0x20 || c == 0x7f || c == '%' || strings.IndexByte(reserved, c) >= 0
+		if seqID && !escape {
+			escape = !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(".:^*$@!+_?-|", c) >= 0)
+		}
+		if escape {
+			sb.WriteString(fmt.Sprintf("%%%02X", c))
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// WriteGFF writes features as GFF3 lines.
+func WriteGFF(w io.Writer, features []GFFFeature) error {
+	txt := parseio.NewTxtBuilder()
+	for _, feature := range features {
+		txt.WriteString(feature.ToString())
+		txt.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, txt.String())
+	return err
+}
+
+// WriteGFFFile writes the features of records to a GFF3 file with a sequence-region pragma for
+// each record, gzipping it when filename ends with .gz.
+func WriteGFFFile(filename string, records []Record, source string) error {
+	writer := parseio.NewWriter(filename)
+	defer writer.Close()
+
+	if _, err := io.WriteString(writer, "##gff-version 3\n"); err != nil {
+		return err
+	}
+	for _, record := range records {
+		features, err := GFFFeatures(record, source)
+		if err != nil {
+			return fmt.Errorf("%s: %v", record.RecordID(), err)
+		}
+		length := len(record.RecordSequence())
+		for _, feature := range features {
+			if feature.End > length {
+				length = feature.End
+			}
+		}
+		if len(features) > 0 {
+			if _, err = fmt.Fprintf(writer, "##sequence-region %s 1 %d\n", gffEscape(features[0].SeqID, true, ""), length); err != nil {
+				return err
+			}
+		}
+		if err = WriteGFF(writer, features); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
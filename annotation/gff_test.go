@@ -0,0 +1,80 @@
+package annotation
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGFFFeatures(t *testing.T) {
+	entry := &GenBankEntry{
+		Locus:     "TEST",
+		Accession: []string{"AB000001"},
+		Sequence:  strings.Repeat("acgt", 50),
+		Features: []Feature{
+			{Key: "source", Location: "1..200", Qualifiers: map[string]string{"/organism": "Homo sapiens", "/db_xref": "taxon:9606"}},
+			{Key: "gene", Location: "complement(<10..150)", Qualifiers: map[string]string{"/gene": "tst", "/note": "a; b=c, 5% d"}},
+			{Key: "mRNA", Location: "complement(join(<10..50,100..150))", Qualifiers: map[string]string{"/gene": "tst", "/product": "test"}},
+			{Key: "CDS", Location: "complement(join(20..50,100..140))", Qualifiers: map[string]string{
+				"/gene": "tst", "/codon_start": "2", "/protein_id": "BAA00001.1", "/translation": "MAAA", "/pseudo": "",
+			}},
+			{Key: "misc_feature", Location: "60^61", Qualifiers: map[string]string{}},
+			{Key: "repeat_region", Location: "J00001.1:1..10", Qualifiers: map[string]string{}},
+		},
+	}
+	features, err := GFFFeatures(entry, "GenBank")
+	if err != nil {
+		t.Fatalf("GFFFeatures failed: %v", err)
+	}
+	var lines []string
+	for _, feature := range features {
+		lines = append(lines, feature.ToString())
+	}
+	expected := []string{
+		"AB000001\tGenBank\tregion\t1\t200\t.\t+\t.\tDbxref=taxon:9606;organism=Homo sapiens",
+		"AB000001\tGenBank\tgene\t10\t150\t.\t-\t.\tID=gene-tst;Name=tst;Note=a%3B b%3Dc%2C 5%25 d;gene=tst;partial=true;start_range=.,10",
+		"AB000001\tGenBank\tmRNA\t10\t150\t.\t-\t.\tID=rna-tst;Parent=gene-tst;gene=tst;partial=true;product=test;start_range=.,10",
+		"AB000001\tGenBank\texon\t100\t150\t.\t-\t.\tParent=rna-tst",
+		"AB000001\tGenBank\texon\t10\t50\t.\t-\t.\tParent=rna-tst",
+		"AB000001\tGenBank\tCDS\t100\t140\t.\t-\t1\tID=cds-BAA00001.1;Parent=rna-tst;codon_start=2;gene=tst;protein_id=BAA00001.1;pseudo=true",
+		"AB000001\tGenBank\tCDS\t20\t50\t.\t-\t2\tID=cds-BAA00001.1;Parent=rna-tst;codon_start=2;gene=tst;protein_id=BAA00001.1;pseudo=true",
+		"AB000001\tGenBank\tsequence_feature\t60\t60\t.\t+\t.\t.",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Error: GFFFeatures() =\n%s\nexpected:\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestWriteGFFFile(t *testing.T) {
+	reader := NewGenBankReader("testdata/human.biological_region.gbff.gz")
+	defer reader.Close()
+	var records []Record
+	for len(records) < 10 {
+		entry, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		records = append(records, entry)
+	}
+
+	filename := t.TempDir() + "/test.gff3"
+	if err := WriteGFFFile(filename, records, "RefSeq"); err != nil {
+		t.Fatalf("WriteGFFFile failed: %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if lines[0] != "##gff-version 3" || lines[1] != "##sequence-region NG_055818 1 655" {
+		t.Errorf("Error: WriteGFFFile() header = %q", lines[:2])
+	}
+	if !strings.HasPrefix(lines[2], "NG_055818\tRefSeq\tregion\t1\t655\t.\t+\t.\tDbxref=taxon:9606;") {
+		t.Errorf("Error: WriteGFFFile() line = %q", lines[2])
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "##") && len(strings.Split(line, "\t")) != 9 {
+			t.Errorf("Error: GFF3 line does not have 9 columns: %q", line)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+package annotation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+)
+
+// GFFReader streams the features of a plain or gzipped GFF3 or GTF file one line at a time.
+// Directives are collected as they are read and the sequences of a trailing ##FASTA section are
+// kept by name once Read returns io.EOF.
+type GFFReader struct {
+	filename   string
+	scanner    *parseio.Scanalyzer
+	Directives []string          // ## and #! header lines, e.g. ##sequence-region
+	Sequences  map[string]string // Sequences of the ##FASTA section by name
+}
+
+// GFFNode is a feature of a GFF3 hierarchy. A feature spread over several lines sharing an ID,
+// such as a CDS, has one node holding all of its lines, and a feature can have several parents.
+type GFFNode struct {
+	ID       string
+	Lines    []GFFFeature
+	Parents  []*GFFNode
+	Children []*GFFNode
+}
+
+// NewGFFReader opens a GFF3 or GTF file for reading.
+func NewGFFReader(filename string) *GFFReader {
+	return &GFFReader{
+		filename:  filename,
+		scanner:   parseio.NewScanner(filename),
+		Sequences: make(map[string]string),
+	}
+}
+
+// Read returns the next feature of the file, or io.EOF once every feature has been read.
+// Parsing errors report the file name and line number.
+func (r *GFFReader) Read() (*GFFFeature, error) {
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case line == "##FASTA" || strings.HasPrefix(line, ">"):
+			if err := r.readFasta(line); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", r.filename, r.scanner.Line(), err)
+			}
+			return nil, io.EOF
+		case strings.HasPrefix(line, "##") || strings.HasPrefix(line, "#!"):
+			r.Directives = append(r.Directives, line)
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			feature, err := ParseGFFLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", r.filename, r.scanner.Line(), err)
+			}
+			return &feature, nil
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s:%d: %v", r.filename, r.scanner.Line()+1, err)
+	}
+	return nil, io.EOF
+}
+
+// Close closes the underlying file.
+func (r *GFFReader) Close() error {
+	return r.scanner.Close()
+}
+
+// readFasta reads the FASTA section that ends a GFF3 file.
+func (r *GFFReader) readFasta(line string) error {
+	var name string
+	var seq strings.Builder
+	for {
+		switch {
+		case strings.HasPrefix(line, ">"):
+			if name != "" {
+				r.Sequences[name] = seq.String()
+			}
+			seq.Reset()
+			fields := strings.Fields(line[1:])
+			if len(fields) == 0 {
+				return fmt.Errorf("FASTA header without a name")
+			}
+			name = fields[0]
+		case line == "##FASTA" || strings.TrimSpace(line) == "":
+		default:
+			seq.WriteString(strings.TrimSpace(line))
+		}
+		if !r.scanner.Scan() {
+			break
+		}
+		line = r.scanner.Text()
+	}
+	if name != "" {
+		r.Sequences[name] = seq.String()
+	}
+	return r.scanner.Err()
+}
+
+// ParseGFFLine parses a GFF3 or GTF feature line. GTF attributes (gene_id "ENSG0001"; tag "basic";)
+// are recognized by the space after the first attribute name, and GFF3 attributes are split on
+// commas and percent-decoded.
+func ParseGFFLine(line string) (GFFFeature, error) {
+	columns := strings.Split(line, "\t")
+	if len(columns) != 9 {
+		return GFFFeature{}, fmt.Errorf("expected 9 tab separated columns, found %d", len(columns))
+	}
+	start, err := strconv.Atoi(columns[3])
+	if err != nil {
+		return GFFFeature{}, fmt.Errorf("invalid start %q", columns[3])
+	}
+	end, err := strconv.Atoi(columns[4])
+	if err != nil {
+		return GFFFeature{}, fmt.Errorf("invalid end %q", columns[4])
+	}
+	if len(columns[6]) != 1 || strings.IndexByte("+-.?", columns[6][0]) < 0 {
+		return GFFFeature{}, fmt.Errorf("invalid strand %q", columns[6])
+	}
+	feature := GFFFeature{
+		SeqID:      gffUnescape(columns[0]),
+		Source:     gffUnescape(columns[1]),
+		Type:       gffUnescape(columns[2]),
+		Start:      start,
+		End:        end,
+		Score:      columns[5],
+		Strand:     columns[6][0],
+		Phase:      columns[7],
+		Attributes: make(map[string][]string),
+	}
+	attributes := strings.TrimSpace(columns[8])
+	if attributes == "." || attributes == "" {
+		return feature, nil
+	}
+	if first, _, _ := strings.Cut(attributes, ";"); len(strings.Fields(first)) > 1 && !strings.Contains(strings.Fields(first)[0], "=") {
+		return feature, parseGTFAttributes(feature.Attributes, attributes)
+	}
+	for _, attribute := range strings.Split(attributes, ";") {
+		if attribute = strings.TrimSpace(attribute); attribute == "" {
+			continue
+		}
+		key, value, found := strings.Cut(attribute, "=")
+		if !found {
+			return feature, fmt.Errorf("attribute %q is not a key=value pair", attribute)
+		}
+		key = gffUnescape(key)
+		for _, v := range strings.Split(value, ",") {
+			feature.Attributes[key] = append(feature.Attributes[key], gffUnescape(v))
+		}
+	}
+	return feature, nil
+}
+
+// parseGTFAttributes parses GTF attributes, appending the values of repeated keys such as tag.
+func parseGTFAttributes(attributes map[string][]string, text string) error {
+	for _, attribute := range strings.Split(text, ";") {
+		if attribute = strings.TrimSpace(attribute); attribute == "" {
+			continue
+		}
+		key, value, found := strings.Cut(attribute, " ")
+		if !found {
+			return fmt.Errorf("attribute %q has no value", attribute)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		attributes[key] = append(attributes[key], value)
+	}
+	return nil
+}
+
+// gffUnescape decodes the %XX escapes of a GFF3 field, leaving malformed escapes as they are.
+func gffUnescape(text string) string {
+	if !strings.Contains(text, "%") {
+		return text
+	}
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '%' && i+2 < len(text) {
+			if b, err := strconv.ParseUint(text[i+1:i+3], 16, 8); err == nil {
+				sb.WriteByte(byte(b))
+				i += 2
+				continue
+			}
+		}
+		sb.WriteByte(text[i])
+	}
+	return sb.String()
+}
+
+// gffID returns the identifier of a feature: its ID, or for GTF genes and transcripts their
+// gene_id and transcript_id.
+func gffID(f GFFFeature) string {
+	if ids := f.Attributes["ID"]; len(ids) > 0 {
+		return ids[0]
+	}
+	switch {
+	case f.Type == "gene" && len(f.Attributes["gene_id"]) > 0:
+		return "gene:" + f.Attributes["gene_id"][0]
+	case f.Type == "transcript" && len(f.Attributes["transcript_id"]) > 0:
+		return "transcript:" + f.Attributes["transcript_id"][0]
+	case f.Type == "CDS" && len(f.Attributes["transcript_id"]) > 0:
+		// The CDS lines of a GTF transcript form one feature
+		return "cds:" + f.Attributes["transcript_id"][0]
+	}
+	return ""
+}
+
+// gffParents returns the parent identifiers of a feature: its Parent values, or for GTF lines the
+// transcript or gene they belong to.
+func gffParents(f GFFFeature) []string {
+	if parents, ok := f.Attributes["Parent"]; ok {
+		return parents
+	}
+	if _, ok := f.Attributes["ID"]; ok {
+		return nil
+	}
+	switch {
+	case f.Type == "gene":
+		return nil
+	case f.Type != "transcript" && len(f.Attributes["transcript_id"]) > 0:
+		return []string{"transcript:" + f.Attributes["transcript_id"][0]}
+	case len(f.Attributes["gene_id"]) > 0:
+		return []string{"gene:" + f.Attributes["gene_id"][0]}
+	}
+	return nil
+}
+
+// BuildGFFHierarchy links features into trees through their ID and Parent attributes, or the
+// gene_id and transcript_id attributes of GTF files, and returns the features without a parent
+// in file order. Lines sharing an ID are merged into one node and features whose parent is missing
+// from the file are returned as roots.
+func BuildGFFHierarchy(features []GFFFeature) []*GFFNode {
+	var nodes []*GFFNode
+	byID := make(map[string]*GFFNode)
+	for _, feature := range features {
+		id := gffID(feature)
+		if node, ok := byID[id]; ok && id != "" {
+			node.Lines = append(node.Lines, feature)
+			continue
+		}
+		node := &GFFNode{ID: id, Lines: []GFFFeature{feature}}
+		if id != "" {
+			byID[id] = node
+		}
+		nodes = append(nodes, node)
+	}
+
+	var roots []*GFFNode
+	for _, node := range nodes {
+		for _, id := range gffParents(node.Lines[0]) {
+			if parent, ok := byID[id]; ok && parent != node {
+				node.Parents = append(node.Parents, parent)
+				parent.Children = append(parent.Children, node)
+			}
+		}
+		if len(node.Parents) == 0 {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+// insdcKeys maps Sequence Ontology terms back to INSDC feature keys. Terms that are not listed
+// are kept as the key.
+var insdcKeys = map[string]string{
+	"five_prime_UTR": "5'UTR", "three_prime_UTR": "3'UTR", "gap": "assembly_gap", "lnc_RNA": "ncRNA",
+	"mature_protein_region": "mat_peptide", "miRNA": "ncRNA", "mobile_genetic_element": "mobile_element",
+	"origin_of_replication": "rep_origin", "polyA_signal_sequence": "polyA_signal", "primary_transcript": "precursor_RNA",
+	"protein_binding_site": "protein_bind", "pseudogene": "gene", "recombination_feature": "misc_recomb",
+	"regulatory_region": "regulatory", "sequence_alteration": "variation", "sequence_difference": "misc_difference",
+	"sequence_feature": "misc_feature", "signal_peptide": "sig_peptide", "snoRNA": "ncRNA", "snRNA": "ncRNA",
+	"start_codon": "misc_feature", "stop_codon": "misc_feature", "transcript": "misc_RNA",
+}
+
+// Feature converts a node to an INSDC feature. The lines of the node are joined into one
+// location and transcripts take their location from their exons. The phase of a CDS becomes
+// /codon_start and attributes become qualifiers as described for gffQualifiers. GTF transcripts
+// with a protein_coding biotype become mRNA.
+func (n *GFFNode) Feature() Feature {
+	first := n.Lines[0]
+	key := first.Type
+	if insdc, ok := insdcKeys[key]; ok {
+		key = insdc
+	}
+	for _, biotype := range append(first.Attributes["transcript_biotype"], first.Attributes["transcript_type"]...) {
+		if key == "misc_RNA" && biotype == "protein_coding" {
+			key = "mRNA"
+		}
+	}
+
+	lines := n.Lines
+	if len(lines) == 1 && gffTranscripts[key] {
+		var exons []GFFFeature
+		for _, child := range n.Children {
+			if child.Lines[0].Type == "exon" {
+				exons = append(exons, child.Lines...)
+			}
+		}
+		if len(exons) > 0 {
+			lines = exons
+		}
+	}
+	lines = append([]GFFFeature(nil), lines...)
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Start < lines[j].Start })
+
+	spans := make([]string, len(lines))
+	for i, line := range lines {
+		if line.Start == line.End {
+			spans[i] = strconv.Itoa(line.Start)
+		} else {
+			spans[i] = fmt.Sprintf("%d..%d", line.Start, line.End)
+		}
+	}
+	location := spans[0]
+	if len(spans) > 1 {
+		location = "join(" + strings.Join(spans, ",") + ")"
+	}
+	if first.Strand == '-' {
+		location = "complement(" + location + ")"
+	}
+
+	qualifiers := gffQualifiers(key, first.Attributes)
+	if key == "CDS" {
+		// The phase of the 5' most line gives the codon start
+		fivePrime := lines[0]
+		if first.Strand == '-' {
+			fivePrime = lines[len(lines)-1]
+		}
+		if phase, err := strconv.Atoi(fivePrime.Phase); err == nil {
+			qualifiers["/codon_start"] = strconv.Itoa(phase + 1)
+		}
+	}
+	return Feature{Key: key, Location: location, Qualifiers: qualifiers}
+}
+
+// gffQualifiers converts the attributes of a feature with the given INSDC key to qualifiers.
+// Note and Dbxref become /note and /db_xref, the Name of a gene and the GTF gene_name become
+// /gene and the Name of other features /standard_name. Attributes named after an INSDC qualifier,
+// as written by GFFFeatures, are kept, while the others, such as Alias or gbkey, are added to
+// /note as name=value. ID and Parent are dropped since they are held by the hierarchy.
+func gffQualifiers(key string, attributes map[string][]string) map[string]string {
+	qualifiers := make(map[string]string)
+	var notes, extra []string
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		values := attributes[name]
+		qualifier := "/" + name
+		switch {
+		case name == "ID" || name == "Parent":
+			continue
+		case name == "Note":
+			notes = append([]string(nil), values...)
+			continue
+		case name == "Dbxref":
+			qualifier = "/db_xref"
+		case name == "Name" && key == "gene", name == "gene_name":
+			if _, ok := attributes["gene"]; ok {
+				continue
+			}
+			qualifier = "/gene"
+		case name == "Name":
+			qualifier = "/standard_name"
+		case !featureQualifiers[qualifier]:
+			for _, value := range values {
+				extra = append(extra, name+"="+value)
+			}
+			continue
+		}
+		qualifiers[qualifier] = strings.Join(values, "\n")
+	}
+	if notes = append(notes, extra...); len(notes) > 0 {
+		qualifiers["/note"] = strings.Join(notes, "\n")
+	}
+	return qualifiers
+}
+
+// GFFToFeatures converts every feature of a hierarchy to INSDC features, parents before their children.
+// Each node is converted once even when it has several parents.
+func GFFToFeatures(roots []*GFFNode) []Feature {
+	var features []Feature
+	seen := make(map[*GFFNode]bool)
+	var visit func(node *GFFNode)
+	visit = func(node *GFFNode) {
+		if seen[node] {
+			return
+		}
+		seen[node] = true
+		features = append(features, node.Feature())
+		for _, child := range node.Children {
+			visit(child)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return features
+}
+
+// AttachGFF adds the GFF features on seqID to the feature table of the GenBank record and returns
+// how many were added. An empty seqID matches the locus, accessions and version of the record.
+func (e *GenBankEntry) AttachGFF(features []GFFFeature, seqID string) int {
+	ids := []string{seqID}
+	if seqID == "" {
+		ids = append([]string{e.Locus}, e.Accession...)
+		if fields := strings.Fields(e.Version); len(fields) > 0 {
+			ids = append(ids, fields[0])
+		}
+	}
+	converted := GFFToFeatures(BuildGFFHierarchy(gffOnSequence(features, ids)))
+	e.Features = append(e.Features, converted...)
+	return len(converted)
+}
+
+// AttachGFF adds the GFF features on seqID to the feature table of the EMBL entry and returns
+// how many were added. An empty seqID matches the identifier, accessions and version of the entry.
+func (e *EMBLEntry) AttachGFF(features []GFFFeature, seqID string) int {
+	ids := []string{seqID}
+	if seqID == "" {
+		ids = append([]string{e.ID}, e.Accession...)
+		if len(e.Accession) > 0 && e.Version != "" {
+			ids = append(ids, e.Accession[0]+"."+e.Version)
+		}
+	}
+	converted := GFFToFeatures(BuildGFFHierarchy(gffOnSequence(features, ids)))
+	e.Features = append(e.Features, converted...)
+	return len(converted)
+}
+
+// gffOnSequence returns the features whose sequence ID is one of ids.
+func gffOnSequence(features []GFFFeature, ids []string) []GFFFeature {
+	var selected []GFFFeature
+	for _, feature := range features {
+		for _, id := range ids {
+			if id != "" && feature.SeqID == id {
+				selected = append(selected, feature)
+				break
+			}
+		}
+	}
+	return selected
+}
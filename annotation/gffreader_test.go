@@ -0,0 +1,163 @@
+package annotation
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+const gff3Data = `##gff-version 3
+##sequence-region ctg123 1 60
+# a comment
+ctg123	.	gene	10	50	.	-	.	ID=gene00001;Name=EDEN;Alias=EDN;gbkey=Gene;Note=protein kinase%3B putative
+ctg123	.	mRNA	10	50	.	-	.	ID=mRNA00001;Parent=gene00001;Name=EDEN.1
+ctg123	.	mRNA	10	40	.	-	.	ID=mRNA00002;Parent=gene00001;Name=EDEN.2
+ctg123	.	exon	10	20	.	-	.	ID=exon00001;Parent=mRNA00001,mRNA00002
+ctg123	.	exon	30	50	.	-	.	ID=exon00002;Parent=mRNA00001
+ctg123	.	exon	30	40	.	-	.	ID=exon00003;Parent=mRNA00002
+ctg123	.	CDS	15	20	.	-	1	ID=cds00001;Parent=mRNA00001;Dbxref=GeneID:1,UniProtKB:P1
+ctg123	.	CDS	30	45	.	-	2	ID=cds00001;Parent=mRNA00001;Dbxref=GeneID:1,UniProtKB:P1
+###
+##FASTA
+>ctg123 test contig
+aaaaaaaaaacccccccccc
+gggggggggg
+>ctg124
+tttt
+`
+
+func TestGFFReader(t *testing.T) {
+	if tmpfile, err := os.CreateTemp("", "*.gff3"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.WriteString(gff3Data)
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+
+		reader := NewGFFReader(tmpfile.Name())
+		defer reader.Close()
+		var features []GFFFeature
+		for {
+			feature, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			features = append(features, *feature)
+		}
+		if len(features) != 8 {
+			t.Fatalf("Error: expected 8 features, got %d", len(features))
+		}
+		if !reflect.DeepEqual(reader.Directives, []string{"##gff-version 3", "##sequence-region ctg123 1 60", "###"}) {
+			t.Errorf("Error: Directives = %q", reader.Directives)
+		}
+		if !reflect.DeepEqual(reader.Sequences, map[string]string{"ctg123": "aaaaaaaaaaccccccccccgggggggggg", "ctg124": "tttt"}) {
+			t.Errorf("Error: Sequences = %q", reader.Sequences)
+		}
+		if note := features[0].Attributes["Note"]; !reflect.DeepEqual(note, []string{"protein kinase; putative"}) {
+			t.Errorf("Error: escaped attribute parsed as %q", note)
+		}
+
+		roots := BuildGFFHierarchy(features)
+		if len(roots) != 1 || roots[0].ID != "gene00001" || len(roots[0].Children) != 2 {
+			t.Fatalf("Error: BuildGFFHierarchy() returned %d roots", len(roots))
+		}
+		rna1, rna2 := roots[0].Children[0], roots[0].Children[1]
+		if len(rna1.Children) != 3 || len(rna2.Children) != 2 || rna1.Children[0] != rna2.Children[0] || len(rna1.Children[0].Parents) != 2 {
+			t.Errorf("Error: exon00001 is not shared by both transcripts")
+		}
+		if cds := rna1.Children[2]; cds.ID != "cds00001" || len(cds.Lines) != 2 {
+			t.Errorf("Error: CDS lines were not merged: %+v", cds)
+		}
+
+		converted := GFFToFeatures(roots)
+		expected := []Feature{
+			{Key: "gene", Location: "complement(10..50)", Qualifiers: map[string]string{"/gene": "EDEN", "/note": "protein kinase; putative\nAlias=EDN\ngbkey=Gene"}},
+			{Key: "mRNA", Location: "complement(join(10..20,30..50))", Qualifiers: map[string]string{"/standard_name": "EDEN.1"}},
+			{Key: "exon", Location: "complement(10..20)", Qualifiers: map[string]string{}},
+			{Key: "exon", Location: "complement(30..50)", Qualifiers: map[string]string{}},
+			{Key: "CDS", Location: "complement(join(15..20,30..45))", Qualifiers: map[string]string{"/db_xref": "GeneID:1\nUniProtKB:P1", "/codon_start": "3"}},
+			{Key: "mRNA", Location: "complement(join(10..20,30..40))", Qualifiers: map[string]string{"/standard_name": "EDEN.2"}},
+			{Key: "exon", Location: "complement(30..40)", Qualifiers: map[string]string{}},
+		}
+		if !reflect.DeepEqual(converted, expected) {
+			t.Errorf("Error: GFFToFeatures() = %+v\nexpected: %+v", converted, expected)
+		}
+
+		// The qualifiers are those of the INSDC feature table
+		entry := &GenBankEntry{Locus: "ctg123", Length: 60, Features: converted}
+		for _, issue := range ValidateGenBank(entry, RecordLines{}) {
+			if strings.Contains(issue.Message, "qualifier") {
+				t.Errorf("Error: Validate() of the converted features reported %v", issue)
+			}
+		}
+	}
+}
+
+func TestParseGTF(t *testing.T) {
+	gtf := []string{
+		"1\thavana\tgene\t11869\t14409\t.\t+\t.\tgene_id \"ENSG00000223972\"; gene_name \"DDX11L1\";",
+		"1\thavana\ttranscript\t11869\t14409\t.\t+\t.\tgene_id \"ENSG00000223972\"; transcript_id \"ENST00000456328\"; tag \"basic\"; tag \"Ensembl_canonical\";",
+		"1\thavana\texon\t11869\t12227\t.\t+\t.\tgene_id \"ENSG00000223972\"; transcript_id \"ENST00000456328\"; exon_number 1;",
+		"1\thavana\texon\t12613\t12721\t.\t+\t.\tgene_id \"ENSG00000223972\"; transcript_id \"ENST00000456328\"; exon_number 2;",
+	}
+	var features []GFFFeature
+	for _, line := range gtf {
+		feature, err := ParseGFFLine(line)
+		if err != nil {
+			t.Fatalf("ParseGFFLine failed: %v", err)
+		}
+		features = append(features, feature)
+	}
+	if tags := features[1].Attributes["tag"]; !reflect.DeepEqual(tags, []string{"basic", "Ensembl_canonical"}) {
+		t.Errorf("Error: repeated GTF attribute parsed as %q", tags)
+	}
+	if number := features[2].Attributes["exon_number"]; !reflect.DeepEqual(number, []string{"1"}) {
+		t.Errorf("Error: unquoted GTF attribute parsed as %q", number)
+	}
+
+	roots := BuildGFFHierarchy(features)
+	if len(roots) != 1 || len(roots[0].Children) != 1 || len(roots[0].Children[0].Children) != 2 {
+		t.Fatalf("Error: BuildGFFHierarchy() did not link the GTF gene, transcript and exons")
+	}
+
+	entry := &GenBankEntry{Locus: "NC_000001", Accession: []string{"NC_000001"}, Version: "NC_000001.11"}
+	if n := entry.AttachGFF(features, ""); n != 0 {
+		t.Errorf("Error: AttachGFF() attached %d features to a different sequence", n)
+	}
+	if n := entry.AttachGFF(features, "1"); n != 4 || entry.Features[1].Location != "join(11869..12227,12613..12721)" ||
+		entry.Features[1].Key != "misc_RNA" || entry.Features[1].Qualifiers["/transcript_id"] != "ENST00000456328" ||
+		entry.Features[1].Qualifiers["/note"] != "gene_id=ENSG00000223972\ntag=basic\ntag=Ensembl_canonical" || entry.Features[0].Qualifiers["/gene"] != "DDX11L1" {
+		t.Errorf("Error: AttachGFF() = %d, features %+v", n, entry.Features)
+	}
+}
+
+func TestGFFRoundTrip(t *testing.T) {
+	reader := NewGenBankReader("testdata/human.biological_region.gbff.gz")
+	defer reader.Close()
+	for count := 0; count < 100; count++ {
+		entry, err := reader.Read()
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		features, err := GFFFeatures(entry, "RefSeq")
+		if err != nil {
+			t.Fatalf("GFFFeatures failed: %v", err)
+		}
+		for _, feature := range features {
+			parsed, err := ParseGFFLine(feature.ToString())
+			if err != nil || !reflect.DeepEqual(parsed, feature) {
+				t.Fatalf("Error: ParseGFFLine(%q) = %+v, %v", feature.ToString(), parsed, err)
+			}
+		}
+	}
+
+	if _, err := ParseGFFLine("ctg123\t.\tgene\tten\t50\t.\t+\t.\tID=gene00001"); err == nil || !strings.Contains(err.Error(), "invalid start") {
+		t.Errorf("Error: ParseGFFLine() expected an invalid start error, got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package annotation
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// xmlNode is a generic XML element. INSDSeq and GBSeq XML share one layout and differ only in the
+// INSD and GB prefixes of their element names, so both are decoded into nodes and read by the
+// name without its prefix.
+type xmlNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Content  string     `xml:",chardata"`
+	Children []xmlNode  `xml:",any"`
+}
+
+// ParseINSDSeq parses one INSDSeq or GBSeq element at a time from the provided decoder, as returned
+// by NCBI efetch with retmode=xml, returning io.EOF once there are no more records.
+func ParseINSDSeq(decoder *xml.Decoder) (*GenBankEntry, error) {
+	for {
+		tok, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch se := tok.(type) {
+		case xml.StartElement:
+			if se.Name.Local == "INSDSeq" || se.Name.Local == "GBSeq" {
+				var node xmlNode
+				if err = decoder.DecodeElement(&node, &se); err != nil {
+					return nil, err
+				}
+				return insdSeqEntry(node), nil
+			}
+		}
+	}
+}
+
+// name returns the element name without its INSD or GB prefix, e.g. Seq_locus.
+func (n xmlNode) name() string {
+	if name, found := strings.CutPrefix(n.XMLName.Local, "INSD"); found {
+		return name
+	}
+	return strings.TrimPrefix(n.XMLName.Local, "GB")
+}
+
+// child returns the first child with the unprefixed name, or an empty node.
+func (n xmlNode) child(name string) xmlNode {
+	for _, child := range n.Children {
+		if child.name() == name {
+			return child
+		}
+	}
+	return xmlNode{}
+}
+
+// text returns the trimmed content of the first child with the unprefixed name.
+func (n xmlNode) text(name string) string {
+	return strings.TrimSpace(n.child(name).Content)
+}
+
+// texts returns the trimmed content of every grandchild below the child with the unprefixed
+// name, e.g. the GBKeyword elements of GBSeq_keywords.
+func (n xmlNode) texts(name string) []string {
+	var values []string
+	for _, child := range n.child(name).Children {
+		values = append(values, strings.TrimSpace(child.Content))
+	}
+	return values
+}
+
+// insdSeqEntry converts an INSDSeq element to a GenBankEntry using the flat file conventions of
+// ParseGenBank, so that both sources produce the same values. Comment lines, which the XML
+// separates with a tilde, are separated by newlines.
+func insdSeqEntry(seq xmlNode) *GenBankEntry {
+	entry := &GenBankEntry{
+		Locus:      seq.text("Seq_locus"),
+		Topology:   seq.text("Seq_topology"),
+		Division:   seq.text("Seq_division"),
+		Date:       seq.text("Seq_update-date"),
+		Definition: seq.text("Seq_definition"),
+		Version:    seq.text("Seq_accession-version"),
+		DBSource:   seq.text("Seq_source-db"),
+		Keywords:   seq.texts("Seq_keywords"),
+		Source:     seq.text("Seq_source"),
+		Organism:   seq.text("Seq_organism"),
+		Comment:    strings.ReplaceAll(seq.text("Seq_comment"), "~", "\n"),
+		Primary:    seq.text("Seq_primary"),
+		Contig:     seq.text("Seq_contig"),
+		Sequence:   seq.text("Seq_sequence"),
+	}
+	if length, err := strconv.Atoi(seq.text("Seq_length")); err == nil {
+		entry.Length = length
+	}
+
+	entry.MoleculeType = seq.text("Seq_moltype")
+	if entry.MoleculeType == "AA" {
+		entry.MoleculeType = "aa"
+	} else {
+		switch seq.text("Seq_strandedness") {
+		case "single":
+			entry.MoleculeType = "ss-" + entry.MoleculeType
+		case "double":
+			entry.MoleculeType = "ds-" + entry.MoleculeType
+		case "mixed":
+			entry.MoleculeType = "ms-" + entry.MoleculeType
+		}
+	}
+
+	// The XML omits the final period of the DEFINITION line
+	if entry.Definition != "" && !strings.HasSuffix(entry.Definition, ".") {
+		entry.Definition += "."
+	}
+	if accession := seq.text("Seq_primary-accession"); accession != "" {
+		entry.Accession = append(entry.Accession, accession)
+	}
+	entry.Accession = append(entry.Accession, seq.texts("Seq_secondary-accessions")...)
+	if taxonomy := strings.TrimSuffix(seq.text("Seq_taxonomy"), "."); taxonomy != "" {
+		entry.Taxonomy = strings.Split(taxonomy, "; ")
+	}
+	if len(entry.Keywords) == 0 {
+		entry.Keywords = nil
+	}
+
+	// Database links are grouped by database as on the DBLINK lines
+	var databases []string
+	ids := make(map[string][]string)
+	for _, xref := range seq.child("Seq_xrefs").Children {
+		database := xref.text("Xref_dbname")
+		if _, ok := ids[database]; !ok {
+			databases = append(databases, database)
+		}
+		ids[database] = append(ids[database], xref.text("Xref_id"))
+	}
+	for _, database := range databases {
+		entry.DBLink = append(entry.DBLink, database+": "+strings.Join(ids[database], ", "))
+	}
+
+	for _, ref := range seq.child("Seq_references").Children {
+		entry.References = append(entry.References, insdSeqReference(ref, entry.IsProtein()))
+	}
+	for _, feature := range seq.child("Seq_feature-table").Children {
+		entry.Features = append(entry.Features, insdSeqFeature(feature))
+	}
+	return entry
+}
+
+// insdSeqReference converts an INSDReference element. Positions such as 1..756 become the
+// "bases 1 to 756" form of the REFERENCE line and authors are joined as on the AUTHORS line.
+func insdSeqReference(ref xmlNode, protein bool) GenBankReference {
+	reference := GenBankReference{
+		Number:     ref.text("Reference_reference"),
+		Consortium: ref.text("Reference_consortium"),
+		Title:      ref.text("Reference_title"),
+		Journal:    ref.text("Reference_journal"),
+		PubMed:     ref.text("Reference_pubmed"),
+		Medline:    ref.text("Reference_medline"),
+		Remark:     ref.text("Reference_remark"),
+	}
+	if position := ref.text("Reference_position"); position != "" {
+		unit := "bases "
+		if protein {
+			unit = "residues "
+		}
+		ranges := strings.Split(position, ";")
+		for i, r := range ranges {
+			ranges[i] = strings.Replace(strings.TrimSpace(r), "..", " to ", 1)
+		}
+		reference.Bases = unit + strings.Join(ranges, "; ")
+	}
+	if authors := ref.texts("Reference_authors"); len(authors) > 1 {
+		reference.Authors = strings.Join(authors[:len(authors)-1], ", ") + " and " + authors[len(authors)-1]
+	} else if len(authors) == 1 {
+		reference.Authors = authors[0]
+	}
+	return reference
+}
+
+// insdSeqFeature converts an INSDFeature element. Qualifier names get the leading slash of the
+// flat file and repeated qualifiers are joined with newlines.
+func insdSeqFeature(feature xmlNode) Feature {
+	f := Feature{
+		Key:        feature.text("Feature_key"),
+		Location:   feature.text("Feature_location"),
+		Qualifiers: make(map[string]string),
+	}
+	for _, qualifier := range feature.child("Feature_quals").Children {
+		key := "/" + qualifier.text("Qualifier_name")
+		value := qualifier.text("Qualifier_value")
+		if previous, ok := f.Qualifiers[key]; ok {
+			value = previous + "\n" + value
+		}
+		f.Qualifiers[key] = value
+	}
+	return f
+}
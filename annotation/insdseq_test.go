@@ -0,0 +1,208 @@
+package annotation
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+const insdSeqData = `<?xml version="1.0"?>
+<!DOCTYPE INSDSet PUBLIC "-//NCBI//INSD INSDSeq/EN" "https://www.ncbi.nlm.nih.gov/dtd/INSD_INSDSeq.dtd">
+<INSDSet>
+  <INSDSeq>
+    <INSDSeq_locus>LISOD</INSDSeq_locus>
+    <INSDSeq_length>756</INSDSeq_length>
+    <INSDSeq_moltype>DNA</INSDSeq_moltype>
+    <INSDSeq_topology>linear</INSDSeq_topology>
+    <INSDSeq_division>BCT</INSDSeq_division>
+    <INSDSeq_update-date>30-JUN-1993</INSDSeq_update-date>
+    <INSDSeq_definition>Listeria ivanovii sod gene for superoxide dismutase</INSDSeq_definition>
+    <INSDSeq_primary-accession>X64011</INSDSeq_primary-accession>
+    <INSDSeq_accession-version>X64011.1</INSDSeq_accession-version>
+    <INSDSeq_secondary-accessions>
+      <INSDSecondary-accn>S78972</INSDSecondary-accn>
+    </INSDSeq_secondary-accessions>
+    <INSDSeq_keywords>
+      <INSDKeyword>sod gene</INSDKeyword>
+      <INSDKeyword>superoxide dismutase</INSDKeyword>
+    </INSDSeq_keywords>
+    <INSDSeq_source>Listeria ivanovii</INSDSeq_source>
+    <INSDSeq_organism>Listeria ivanovii</INSDSeq_organism>
+    <INSDSeq_taxonomy>Bacteria; Firmicutes; Bacillales; Listeriaceae; Listeria</INSDSeq_taxonomy>
+    <INSDSeq_references>
+      <INSDReference>
+        <INSDReference_reference>1</INSDReference_reference>
+        <INSDReference_position>1..756</INSDReference_position>
+        <INSDReference_authors>
+          <INSDAuthor>Haas,A.</INSDAuthor>
+          <INSDAuthor>Goebel,W.</INSDAuthor>
+        </INSDReference_authors>
+        <INSDReference_title>Cloning of a superoxide dismutase gene from Listeria ivanovii by functional complementation in Escherichia coli</INSDReference_title>
+        <INSDReference_journal>Mol. Gen. Genet. 231 (2), 313-322 (1992)</INSDReference_journal>
+        <INSDReference_pubmed>1736100</INSDReference_pubmed>
+      </INSDReference>
+    </INSDSeq_references>
+    <INSDSeq_feature-table>
+      <INSDFeature>
+        <INSDFeature_key>CDS</INSDFeature_key>
+        <INSDFeature_location>109..717</INSDFeature_location>
+        <INSDFeature_intervals>
+          <INSDInterval>
+            <INSDInterval_from>109</INSDInterval_from>
+            <INSDInterval_to>717</INSDInterval_to>
+            <INSDInterval_accession>X64011.1</INSDInterval_accession>
+          </INSDInterval>
+        </INSDFeature_intervals>
+        <INSDFeature_quals>
+          <INSDQualifier>
+            <INSDQualifier_name>gene</INSDQualifier_name>
+            <INSDQualifier_value>sod</INSDQualifier_value>
+          </INSDQualifier>
+          <INSDQualifier>
+            <INSDQualifier_name>product</INSDQualifier_name>
+            <INSDQualifier_value>superoxide dismutase</INSDQualifier_value>
+          </INSDQualifier>
+          <INSDQualifier>
+            <INSDQualifier_name>db_xref</INSDQualifier_name>
+            <INSDQualifier_value>GI:44011</INSDQualifier_value>
+          </INSDQualifier>
+          <INSDQualifier>
+            <INSDQualifier_name>db_xref</INSDQualifier_name>
+            <INSDQualifier_value>SWISS-PROT:P28763</INSDQualifier_value>
+          </INSDQualifier>
+          <INSDQualifier>
+            <INSDQualifier_name>translation</INSDQualifier_name>
+            <INSDQualifier_value>MTYELPKLPYTYDALEPNFDKETMEIHYTKHHNIYVTKLNEAVSGHAELASKPGEELVANLDSVPEEIRGAVRNHGGGHANHTLFWSSLSPNGGGAPTGNLKAAIESEFGTFDEFKEKFNAAAAARFGSGWAWLVVNNGKLEIVSTANQDSPLSEGKTPVLGLDVWEHAYYLKFQNRRPEYIDTFWNVINWDERNKRFDAAK</INSDQualifier_value>
+          </INSDQualifier>
+        </INSDFeature_quals>
+      </INSDFeature>
+    </INSDSeq_feature-table>
+    <INSDSeq_sequence>cgttatttaaggtgttacatagttctatggaaatagggtctatacctttcgccttacaatgtaatttctt</INSDSeq_sequence>
+  </INSDSeq>
+</INSDSet>
+`
+
+const gbSeqData = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE GBSet PUBLIC "-//NCBI//NCBI GBSeq/EN" "https://www.ncbi.nlm.nih.gov/dtd/NCBI_GBSeq.dtd">
+<GBSet>
+  <GBSeq>
+    <GBSeq_locus>NG_055818</GBSeq_locus>
+    <GBSeq_length>655</GBSeq_length>
+    <GBSeq_strandedness>double</GBSeq_strandedness>
+    <GBSeq_moltype>DNA</GBSeq_moltype>
+    <GBSeq_topology>linear</GBSeq_topology>
+    <GBSeq_division>CON</GBSeq_division>
+    <GBSeq_definition>Homo sapiens test region on chromosome 1</GBSeq_definition>
+    <GBSeq_primary-accession>NG_055818</GBSeq_primary-accession>
+    <GBSeq_accession-version>NG_055818.1</GBSeq_accession-version>
+    <GBSeq_comment>REVIEWED REFSEQ: This record has been curated by NCBI staff.~Summary: test record.</GBSeq_comment>
+    <GBSeq_xrefs>
+      <GBXref>
+        <GBXref_dbname>BioProject</GBXref_dbname>
+        <GBXref_id>PRJNA343958</GBXref_id>
+      </GBXref>
+    </GBSeq_xrefs>
+    <GBSeq_references>
+      <GBReference>
+        <GBReference_reference>1</GBReference_reference>
+        <GBReference_position>1..300; 400..655</GBReference_position>
+        <GBReference_consortium>NCBI Genome Project</GBReference_consortium>
+        <GBReference_journal>Unpublished</GBReference_journal>
+      </GBReference>
+    </GBSeq_references>
+    <GBSeq_feature-table>
+      <GBFeature>
+        <GBFeature_key>misc_feature</GBFeature_key>
+        <GBFeature_location>complement(10..20)</GBFeature_location>
+        <GBFeature_quals>
+          <GBQualifier>
+            <GBQualifier_name>pseudo</GBQualifier_name>
+          </GBQualifier>
+        </GBFeature_quals>
+      </GBFeature>
+    </GBSeq_feature-table>
+    <GBSeq_contig>join(NT_077402.3:1..655)</GBSeq_contig>
+  </GBSeq>
+</GBSet>
+`
+
+func TestParseINSDSeq(t *testing.T) {
+	// The INSDSeq record holds the same data as the flat file of TestParseGenBank
+	flat := `LOCUS       LISOD                    756 bp    DNA     linear   BCT 30-JUN-1993
+DEFINITION  Listeria ivanovii sod gene for superoxide dismutase.
+ACCESSION   X64011 S78972
+VERSION     X64011.1
+KEYWORDS    sod gene; superoxide dismutase.
+SOURCE      Listeria ivanovii
+  ORGANISM  Listeria ivanovii
+            Bacteria; Firmicutes; Bacillales; Listeriaceae; Listeria.
+REFERENCE   1  (bases 1 to 756)
+  AUTHORS   Haas,A. and Goebel,W.
+  TITLE     Cloning of a superoxide dismutase gene from Listeria ivanovii by
+            functional complementation in Escherichia coli
+  JOURNAL   Mol. Gen. Genet. 231 (2), 313-322 (1992)
+   PUBMED   1736100
+FEATURES             Location/Qualifiers
+     CDS             109..717
+                     /gene="sod"
+                     /product="superoxide dismutase"
+                     /db_xref="GI:44011"
+                     /db_xref="SWISS-PROT:P28763"
+                     /translation="MTYELPKLPYTYDALEPNFDKETMEIHYTKHHNIYVTKLNEAVS
+                     GHAELASKPGEELVANLDSVPEEIRGAVRNHGGGHANHTLFWSSLSPNGGGAPTGNLK
+                     AAIESEFGTFDEFKEKFNAAAAARFGSGWAWLVVNNGKLEIVSTANQDSPLSEGKTPV
+                     LGLDVWEHAYYLKFQNRRPEYIDTFWNVINWDERNKRFDAAK"
+ORIGIN
+        1 cgttatttaa ggtgttacat agttctatgg aaatagggtc tatacctttc gccttacaat
+       61 gtaatttctt
+//
+`
+	expected, err := ParseGenBank(&parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(flat))})
+	if err != nil {
+		t.Fatalf("ParseGenBank failed: %v", err)
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(insdSeqData))
+	entry, err := ParseINSDSeq(decoder)
+	if err != nil {
+		t.Fatalf("ParseINSDSeq failed: %v", err)
+	}
+	if !reflect.DeepEqual(entry, expected) {
+		t.Errorf("Error: ParseINSDSeq() = %s\nexpected: %s", entry.ToJson(), expected.ToJson())
+	}
+	if _, err = ParseINSDSeq(decoder); err != io.EOF {
+		t.Errorf("Error: expected io.EOF after the last record, got %v", err)
+	}
+}
+
+func TestParseGBSeq(t *testing.T) {
+	entry, err := ParseINSDSeq(xml.NewDecoder(strings.NewReader(gbSeqData)))
+	if err != nil {
+		t.Fatalf("ParseINSDSeq failed: %v", err)
+	}
+	if entry.MoleculeType != "ds-DNA" || entry.Definition != "Homo sapiens test region on chromosome 1." {
+		t.Errorf("Error: LOCUS and DEFINITION parsed as %q, %q", entry.MoleculeType, entry.Definition)
+	}
+	if entry.Comment != "REVIEWED REFSEQ: This record has been curated by NCBI staff.\nSummary: test record." {
+		t.Errorf("Error: COMMENT parsed as %q", entry.Comment)
+	}
+	if !reflect.DeepEqual(entry.DBLink, []string{"BioProject: PRJNA343958"}) {
+		t.Errorf("Error: DBLINK parsed as %q", entry.DBLink)
+	}
+	reference := GenBankReference{Number: "1", Bases: "bases 1 to 300; 400 to 655", Consortium: "NCBI Genome Project", Journal: "Unpublished"}
+	if len(entry.References) != 1 || entry.References[0] != reference {
+		t.Errorf("Error: REFERENCE parsed as %+v, expected: %+v", entry.References, reference)
+	}
+	feature := Feature{Key: "misc_feature", Location: "complement(10..20)", Qualifiers: map[string]string{"/pseudo": ""}}
+	if len(entry.Features) != 1 || !reflect.DeepEqual(entry.Features[0], feature) {
+		t.Errorf("Error: FEATURES parsed as %+v, expected: %+v", entry.Features, feature)
+	}
+	if entry.Contig != "join(NT_077402.3:1..655)" || entry.Sequence != "" {
+		t.Errorf("Error: CONTIG parsed as %q with sequence %q", entry.Contig, entry.Sequence)
+	}
+}
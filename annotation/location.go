@@ -0,0 +1,276 @@
+package annotation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Span is a contiguous stretch of a feature location in 1-based, inclusive coordinates.
+type Span struct {
+	Start        int    `json:"start"`
+	End          int    `json:"end"`
+	Complement   bool   `json:"complement,omitempty"`   // Span is read from the reverse strand
+	PartialStart bool   `json:"partialStart,omitempty"` // Start is beyond the specified base (<)
+	PartialEnd   bool   `json:"partialEnd,omitempty"`   // End is beyond the specified base (>)
+	Between      bool   `json:"between,omitempty"`      // Site between two bases (a^b)
+	OneOf        bool   `json:"oneOf,omitempty"`        // Single base somewhere within Start..End (a.b)
+	Accession    string `json:"accession,omitempty"`    // Remote entry the span refers to, if any
+}
+
+// Location is a parsed INSDC feature location such as complement(join(12..78,134..202)).
+// Spans are stored in the order they are read, so joining them yields the feature from 5' to 3'.
+type Location struct {
+	Operator string `json:"operator,omitempty"` // join, order or empty for a single span
+	Spans    []Span `json:"spans"`
+}
+
+// Len returns the number of bases covered by a span.
+func (s Span) Len() int {
+	if s.Between {
+		return 0
+	}
+	if s.OneOf {
+		return 1
+	}
+	return s.End - s.Start + 1
+}
+
+// Len returns the total number of bases covered by the location.
+func (l Location) Len() int {
+	var length int
+	for _, span := range l.Spans {
+		length += span.Len()
+	}
+	return length
+}
+
+// Start returns the lowest local coordinate of the location, ignoring spans on remote entries.
+func (l Location) Start() int {
+	start := 0
+	for _, span := range l.Spans {
+		if span.Accession == "" && (start == 0 || span.Start < start) {
+			start = span.Start
+		}
+	}
+	return start
+}
+
+// End returns the highest local coordinate of the location, ignoring spans on remote entries.
+func (l Location) End() int {
+	var end int
+	for _, span := range l.Spans {
+		if span.Accession == "" && span.End > end {
+			end = span.End
+		}
+	}
+	return end
+}
+
+// Strand returns 1 when every span is on the forward strand, -1 when every span is on the
+// complement strand and 0 when the location mixes strands or is empty.
+func (l Location) Strand() int {
+	var forward, reverse bool
+	for _, span := range l.Spans {
+		if span.Complement {
+			reverse = true
+		} else {
+			forward = true
+		}
+	}
+	switch {
+	case forward && !reverse:
+		return 1
+	case reverse && !forward:
+		return -1
+	}
+	return 0
+}
+
+// Offset returns the 0-based offset of a local sequence position within the bases extracted by
+// the location, or -1 when the location does not cover the position.
+func (l Location) Offset(position int) int {
+	var offset int
+	for _, span := range l.Spans {
+		if span.Accession == "" && !span.Between && !span.OneOf && position >= span.Start && position <= span.End {
+			if span.Complement {
+				return offset + span.End - position
+			}
+			return offset + position - span.Start
+		}
+		offset += span.Len()
+	}
+	return -1
+}
+
+// Extract returns the bases described by the location from sequence. Spans are joined in order
+// and spans on the complement strand are reverse complemented. Spans of a single base of unknown
+// position, such as 102.110, cannot be extracted.
+func (l Location) Extract(sequence string) (string, error) {
+	var sb strings.Builder
+	sb.Grow(l.Len())
+	for _, span := range l.Spans {
+		if span.Accession != "" {
+			return "", fmt.Errorf("span %d..%d refers to remote entry %s", span.Start, span.End, span.Accession)
+		}
+		if span.Start < 1 || span.End > len(sequence) || span.Start > span.End {
+			return "", fmt.Errorf("span %d..%d is outside of sequence of length %d", span.Start, span.End, len(sequence))
+		}
+		if span.OneOf {
+			return "", fmt.Errorf("span %d.%d is a single base of uncertain position", span.Start, span.End)
+		}
+		if span.Between {
+			continue
+		}
+		bases := sequence[span.Start-1 : span.End]
+		if span.Complement {
+			bases = ReverseComplement(bases)
+		}
+		sb.WriteString(bases)
+	}
+	return sb.String(), nil
+}
+
+// ParseLocation parses an INSDC location string into a Location.
+func ParseLocation(text string) (Location, error) {
+	p := locationParser{text: strings.Join(strings.Fields(text), "")}
+	loc, err := p.parse()
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid location %q: %v", text, err)
+	}
+	if p.pos != len(p.text) {
+		return Location{}, fmt.Errorf("invalid location %q: unexpected %q at offset %d", text, p.text[p.pos:], p.pos)
+	}
+	return loc, nil
+}
+
+// locationParser is a small recursive descent parser over the INSDC location grammar.
+type locationParser struct {
+	text string
+	pos  int
+}
+
+// parse reads one location expression: an operator applied to a list, complement() or a single span.
+func (p *locationParser) parse() (Location, error) {
+	switch {
+	case p.consume("complement("):
+		loc, err := p.parse()
+		if err != nil {
+			return loc, err
+		}
+		if !p.consume(")") {
+			return loc, fmt.Errorf("missing ) after complement")
+		}
+		return loc.complement(), nil
+	case p.consume("join("):
+		return p.parseList("join")
+	case p.consume("order("):
+		return p.parseList("order")
+	}
+	span, err := p.parseSpan()
+	if err != nil {
+		return Location{}, err
+	}
+	return Location{Spans: []Span{span}}, nil
+}
+
+// parseList reads a comma separated list of locations up to the closing parenthesis.
+func (p *locationParser) parseList(operator string) (Location, error) {
+	ans := Location{Operator: operator}
+	for {
+		loc, err := p.parse()
+		if err != nil {
+			return ans, err
+		}
+		ans.Spans = append(ans.Spans, loc.Spans...)
+		if p.consume(",") {
+			continue
+		}
+		if p.consume(")") {
+			return ans, nil
+		}
+		return ans, fmt.Errorf("missing ) after %s", operator)
+	}
+}
+
+// parseSpan reads a single span such as 100, <1..>200, 12^13 or J00194.1:100..202.
+func (p *locationParser) parseSpan() (Span, error) {
+	var span Span
+	if colon := strings.IndexByte(p.text[p.pos:], ':'); colon > 0 {
+		if candidate := p.text[p.pos : p.pos+colon]; isAccession(candidate) {
+			span.Accession = candidate
+			p.pos += colon + 1
+		}
+	}
+	span.PartialStart = p.consume("<")
+	start, err := p.parseInt()
+	if err != nil {
+		return span, err
+	}
+	span.Start, span.End = start, start
+
+	switch {
+	case p.consume(".."):
+		span.PartialEnd = p.consume(">")
+		if span.End, err = p.parseInt(); err != nil {
+			return span, err
+		}
+	case p.consume("^"):
+		span.Between = true
+		if span.End, err = p.parseInt(); err != nil {
+			return span, err
+		}
+	case p.consume("."):
+		// A single base somewhere within the range, e.g. 102.110
+		span.OneOf = true
+		if span.End, err = p.parseInt(); err != nil {
+			return span, err
+		}
+	case p.consume(">"):
+		span.PartialEnd = true
+	}
+	if span.End < span.Start {
+		return span, fmt.Errorf("span %d..%d ends before it starts", span.Start, span.End)
+	}
+	return span, nil
+}
+
+// parseInt reads an unsigned integer at the current position.
+func (p *locationParser) parseInt() (int, error) {
+	begin := p.pos
+	for p.pos < len(p.text) && p.text[p.pos] >= '0' && p.text[p.pos] <= '9' {
+		p.pos++
+	}
+	if begin == p.pos {
+		return 0, fmt.Errorf("expected a position at offset %d", begin)
+	}
+	return strconv.Atoi(p.text[begin:p.pos])
+}
+
+// consume advances past token if the remaining text starts with it.
+func (p *locationParser) consume(token string) bool {
+	if strings.HasPrefix(p.text[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// complement flips the strand of every span and reverses their order.
+func (l Location) complement() Location {
+	spans := make([]Span, len(l.Spans))
+	for i, span := range l.Spans {
+		span.Complement = !span.Complement
+		spans[len(spans)-1-i] = span
+	}
+	return Location{Operator: l.Operator, Spans: spans}
+}
+
+// isAccession reports whether text looks like an entry accession such as AL137784.14.
+func isAccession(text string) bool {
+	first := text[0]
+	if (first < 'A' || first > 'Z') && (first < 'a' || first > 'z') {
+		return false
+	}
+	return !strings.ContainsAny(text, "(),^<>") && !strings.Contains(text, "..")
+}
@@ -0,0 +1,95 @@
+package annotation
+
+import (
+	"reflect"
+	"testing"
+)
+
+var locationTests = []struct {
+	text     string
+	expected Location
+}{
+	{"467", Location{Spans: []Span{{Start: 467, End: 467}}}},
+	{"340..565", Location{Spans: []Span{{Start: 340, End: 565}}}},
+	{"<345..>500", Location{Spans: []Span{{Start: 345, End: 500, PartialStart: true, PartialEnd: true}}}},
+	{"102.110", Location{Spans: []Span{{Start: 102, End: 110, OneOf: true}}}},
+	{"123^124", Location{Spans: []Span{{Start: 123, End: 124, Between: true}}}},
+	{"join(12..78,134..202)", Location{Operator: "join", Spans: []Span{{Start: 12, End: 78}, {Start: 134, End: 202}}}},
+	{"complement(join(2691..4571, 4918..5163))", Location{Operator: "join", Spans: []Span{
+		{Start: 4918, End: 5163, Complement: true},
+		{Start: 2691, End: 4571, Complement: true},
+	}}},
+	{"join(complement(4918..5163),complement(2691..4571))", Location{Operator: "join", Spans: []Span{
+		{Start: 4918, End: 5163, Complement: true},
+		{Start: 2691, End: 4571, Complement: true},
+	}}},
+	{"join(AL137784.14:110302..110956)", Location{Operator: "join", Spans: []Span{
+		{Start: 110302, End: 110956, Accession: "AL137784.14"},
+	}}},
+}
+
+func TestParseLocation(t *testing.T) {
+	for _, test := range locationTests {
+		loc, err := ParseLocation(test.text)
+		if err != nil {
+			t.Errorf("Error: ParseLocation(%s) returned %v", test.text, err)
+			continue
+		}
+		if !reflect.DeepEqual(loc, test.expected) {
+			t.Errorf("Error: ParseLocation(%s) = %+v, expected: %+v", test.text, loc, test.expected)
+		}
+	}
+	for _, text := range []string{"", "join(1..5", "10..2", "complement(1..5", "1..5)"} {
+		if _, err := ParseLocation(text); err == nil {
+			t.Errorf("Error: ParseLocation(%q) expected an error", text)
+		}
+	}
+}
+
+func TestFeatureSequence(t *testing.T) {
+	entry := &EMBLEntry{Sequence: "aaccggttACGT"}
+	tests := []struct {
+		location string
+		expected string
+	}{
+		{"1..4", "aacc"},
+		{"complement(1..4)", "ggtt"},
+		{"join(1..2,5..6)", "aagg"},
+		{"complement(join(1..2,9..10))", "GTtt"},
+		{"order(3,12)", "cT"},
+		{"4^5", ""},
+	}
+	for _, test := range tests {
+		seq, err := entry.FeatureSequence(Feature{Key: "CDS", Location: test.location})
+		if err != nil {
+			t.Errorf("Error: FeatureSequence(%s) returned %v", test.location, err)
+		}
+		if seq != test.expected {
+			t.Errorf("Error: FeatureSequence(%s) = %s, expected: %s", test.location, seq, test.expected)
+		}
+	}
+	for _, location := range []string{"1..13", "J00194.1:1..4", "2.6"} {
+		if _, err := entry.FeatureSequence(Feature{Key: "CDS", Location: location}); err == nil {
+			t.Errorf("Error: FeatureSequence(%s) expected an error", location)
+		}
+	}
+}
+
+func TestReverseComplement(t *testing.T) {
+	if ans := ReverseComplement("ACGTRYNacgtu"); ans != "aacgtNRYACGT" {
+		t.Errorf("Error: ReverseComplement() = %s, expected: aacgtNRYACGT", ans)
+	}
+}
+
+func TestFeatureValues(t *testing.T) {
+	feature := Feature{Qualifiers: map[string]string{"/db_xref": "GI:44011\nSWISS-PROT:P28763", "/pseudo": ""}}
+	if values := feature.Values("/db_xref"); !reflect.DeepEqual(values, []string{"GI:44011", "SWISS-PROT:P28763"}) {
+		t.Errorf("Error: Values(/db_xref) = %q", values)
+	}
+	if values := feature.Values("/pseudo"); !reflect.DeepEqual(values, []string{""}) {
+		t.Errorf("Error: Values(/pseudo) = %q", values)
+	}
+	if values := feature.Values("/gene"); values != nil {
+		t.Errorf("Error: Values(/gene) = %q, expected nil", values)
+	}
+}
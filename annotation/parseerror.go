@@ -0,0 +1,139 @@
+package annotation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+)
+
+// ParseError describes malformed input found while parsing a GenBank or EMBL flat file.
+type ParseError struct {
+	Filename string // Empty when parsing from a scanner rather than a reader
+	Line     int    // 1-based line number of the offending line
+	Record   string // Locus name or ID of the record, empty before its first line was read
+	Text     string // The offending line, empty when the input ended early
+	Err      error
+}
+
+// Error formats the error as "file:line: record: message: text", leaving out the parts that are
+// unknown.
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.Filename != "" {
+		sb.WriteString(e.Filename + ":")
+	}
+	fmt.Fprintf(&sb, "%d: ", e.Line)
+	if e.Record != "" {
+		sb.WriteString("record " + e.Record + ": ")
+	}
+	sb.WriteString(e.Err.Error())
+	if e.Text != "" {
+		fmt.Fprintf(&sb, ": %q", e.Text)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseMode selects how a reader handles a malformed record.
+type ParseMode int
+
+const (
+	Strict  ParseMode = iota // Read returns the first ParseError and stops
+	Lenient                  // Read skips malformed records and collects their errors
+)
+
+// newParseError returns a ParseError for the line the scanner read last.
+func newParseError(scanner *parseio.Scanalyzer, record string, err error) *ParseError {
+	return &ParseError{Line: scanner.Line(), Record: record, Text: scanner.Text(), Err: err}
+}
+
+// flatFileReader holds the state shared by the GenBank and EMBL readers.
+type flatFileReader struct {
+	filename string
+	scanner  *parseio.Scanalyzer
+	lines    RecordLines
+	mode     ParseMode
+	errors   []*ParseError
+}
+
+// SetMode selects strict or lenient parsing for the following calls to Read. Readers are strict
+// unless told otherwise.
+func (r *flatFileReader) SetMode(mode ParseMode) {
+	r.mode = mode
+}
+
+// Errors returns the errors of the records skipped in lenient mode.
+func (r *flatFileReader) Errors() []*ParseError {
+	return r.errors
+}
+
+// Lines returns the line numbers of the record returned by the last call to Read.
+func (r *flatFileReader) Lines() RecordLines {
+	return r.lines
+}
+
+// Close closes the underlying file.
+func (r *flatFileReader) Close() error {
+	return r.scanner.Close()
+}
+
+// recover handles an error returned by a parser. It reports true when the malformed record was
+// skipped in lenient mode and the next record can be read, and otherwise returns the error to
+// pass to the caller with the file name filled in, or io.EOF when a lenient reader reached the
+// end of the input inside a record. start is the prefix of the first line of a record.
+func (r *flatFileReader) recover(err error, start string) (bool, error) {
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		return false, fmt.Errorf("%s: %v", r.filename, err)
+	}
+	parseErr.Filename = r.filename
+	if r.mode == Strict {
+		return false, parseErr
+	}
+	r.errors = append(r.errors, parseErr)
+	if parseErr.Text == "" {
+		return false, io.EOF // The input ended inside the record
+	}
+	skipRecord(r.scanner, start)
+	return true, nil
+}
+
+// skipRecord consumes the rest of a malformed record up to its // terminator, stopping early
+// before a line that starts the next record.
+func skipRecord(scanner *parseio.Scanalyzer, start string) {
+	for next, ok := scanner.Peek(); ok && !strings.HasPrefix(next, start); next, ok = scanner.Peek() {
+		scanner.Scan()
+		if strings.HasPrefix(next, "//") {
+			return
+		}
+	}
+}
+
+// errTruncated is returned for a record that ends without its // terminator.
+var errTruncated = errors.New("record ends without a // line")
+
+// newTruncatedError returns the ParseError for a record without a // terminator that runs into
+// next, the header line of the following record. The header is left to the scanner, so that a
+// lenient reader resumes with the following record.
+func newTruncatedError(scanner *parseio.Scanalyzer, record string, next string) *ParseError {
+	return &ParseError{Line: scanner.Line() + 1, Record: record, Text: next, Err: errTruncated}
+}
+
+// endOfInput returns the error for input that ended while reading a record: io.EOF when no
+// record was started, the error of the scanner, or a ParseError for an unterminated record.
+func endOfInput(scanner *parseio.Scanalyzer, started bool, record string) error {
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("line %d: %w", scanner.Line()+1, err)
+	}
+	if !started {
+		return io.EOF
+	}
+	return &ParseError{Line: scanner.Line(), Record: record, Err: errTruncated}
+}
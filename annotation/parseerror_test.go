@@ -0,0 +1,236 @@
+package annotation
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+// malformedGenBank holds a valid record, a record with an unterminated qualifier, a stray line,
+// a record with a bad LOCUS line, a valid record and a truncated record.
+const malformedGenBank = `LOCUS       GOOD1                     10 bp    DNA     linear   SYN 01-JAN-2000
+ORIGIN
+        1 acgtacgtac
+//
+LOCUS       QUOTE                     10 bp    DNA     linear   SYN 01-JAN-2000
+FEATURES             Location/Qualifiers
+     gene            1..10
+                     /gene="abc
+ORIGIN
+        1 acgtacgtac
+//
+junk
+LOCUS       BADLEN                   ten bp    DNA     linear   SYN 01-JAN-2000
+//
+LOCUS       GOOD2                     10 bp    DNA     linear   SYN 01-JAN-2000
+ORIGIN
+        1 acgtacgtac
+//
+LOCUS       CUT                       10 bp    DNA     linear   SYN 01-JAN-2000
+ORIGIN
+`
+
+func TestGenBankReaderModes(t *testing.T) {
+	if tmpfile, err := os.CreateTemp("", "*.gbff"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.WriteString(malformedGenBank)
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+
+		// Strict mode stops at the first malformed record
+		reader := NewGenBankReader(tmpfile.Name())
+		defer reader.Close()
+		if entry, err := reader.Read(); err != nil || entry.Locus != "GOOD1" {
+			t.Fatalf("Error: Read() = %+v, %v", entry, err)
+		}
+		_, err = reader.Read()
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Error: Read() error %v is not a *ParseError", err)
+		}
+		expected := ParseError{Filename: tmpfile.Name(), Line: 8, Record: "QUOTE", Text: `                     /gene="abc`}
+		if parseErr.Filename != expected.Filename || parseErr.Line != expected.Line || parseErr.Record != expected.Record || parseErr.Text != expected.Text {
+			t.Errorf("Error: Read() error = %+v, expected: %+v", *parseErr, expected)
+		}
+		if message := tmpfile.Name() + `:8: record QUOTE: value of qualifier /gene of feature gene has no closing quote: "                     /gene=\"abc"`; err.Error() != message {
+			t.Errorf("Error: Error() = %s, expected: %s", err.Error(), message)
+		}
+
+		// Lenient mode skips the malformed records and collects their errors
+		lenient := NewGenBankReader(tmpfile.Name())
+		defer lenient.Close()
+		lenient.SetMode(Lenient)
+		var loci []string
+		for {
+			entry, err := lenient.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Error: lenient Read() returned %v", err)
+			}
+			loci = append(loci, entry.Locus)
+		}
+		if strings.Join(loci, ",") != "GOOD1,GOOD2" {
+			t.Errorf("Error: lenient Read() returned %v", loci)
+		}
+		var messages []string
+		for _, e := range lenient.Errors() {
+			messages = append(messages, strings.TrimPrefix(e.Error(), tmpfile.Name()+":"))
+		}
+		expectedMessages := []string{
+			`8: record QUOTE: value of qualifier /gene of feature gene has no closing quote: "                     /gene=\"abc"`,
+			`12: expected LOCUS line: "junk"`,
+			`13: record BADLEN: invalid LOCUS length "ten": "LOCUS       BADLEN                   ten bp    DNA     linear   SYN 01-JAN-2000"`,
+			`20: record CUT: record ends without a // line`,
+		}
+		if strings.Join(messages, "\n") != strings.Join(expectedMessages, "\n") {
+			t.Errorf("Error: Errors() =\n%s\nexpected:\n%s", strings.Join(messages, "\n"), strings.Join(expectedMessages, "\n"))
+		}
+	}
+}
+
+func TestEMBLReaderModes(t *testing.T) {
+	embl := "ID   GOOD; SV 1; linear; genomic DNA; STD; SYN; 4 BP.\nSQ   Sequence 4 BP;\n     acgt                                                               4\n//\n" +
+		"ID   BAD; SV 1; linear; genomic DNA; STD; SYN; 4 BP.\nZZ   unknown line code\n//\n" +
+		"ID   GOOD2; SV 1; linear; genomic DNA; STD; SYN; 4 BP.\n//\n"
+	if tmpfile, err := os.CreateTemp("", "*.embl"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.WriteString(embl)
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+
+		reader := NewEMBLReader(tmpfile.Name())
+		defer reader.Close()
+		reader.SetMode(Lenient)
+		var ids []string
+		for entry, err := reader.Read(); err != io.EOF; entry, err = reader.Read() {
+			if err != nil {
+				t.Fatalf("Error: lenient Read() returned %v", err)
+			}
+			ids = append(ids, entry.ID)
+		}
+		if strings.Join(ids, ",") != "GOOD,GOOD2" || len(reader.Errors()) != 1 || reader.Errors()[0].Line != 6 || reader.Errors()[0].Record != "BAD" {
+			t.Errorf("Error: lenient Read() returned %v with errors %v", ids, reader.Errors())
+		}
+	}
+}
+
+func TestReadErrorsNameFile(t *testing.T) {
+	// A line longer than the scanner buffer makes every reader fail
+	long := strings.Repeat("a", 70000)
+	files := map[string]string{
+		"*.gbff": "LOCUS       LONG                      10 bp    DNA     linear   SYN 01-JAN-2000\nDEFINITION  " + long + "\n",
+		"*.embl": "ID   LONG; SV 1; linear; DNA; STD; SYN; 10 BP.\nDE   " + long + "\n",
+		"*.gff3": "##gff-version 3\n" + long + "\n",
+		"*.bed":  "chr1\t0\t10\n" + long + "\n",
+	}
+	for pattern, text := range files {
+		if tmpfile, err := os.CreateTemp("", pattern); parseio.ExitOnError(err) {
+			defer os.Remove(tmpfile.Name())
+			_, err = tmpfile.WriteString(text)
+			parseio.ExitOnError(err)
+			parseio.ExitOnError(tmpfile.Close())
+
+			var readErr error
+			switch pattern {
+			case "*.gbff":
+				reader := NewGenBankReader(tmpfile.Name())
+				_, readErr = reader.Read()
+				reader.Close()
+			case "*.embl":
+				reader := NewEMBLReader(tmpfile.Name())
+				_, readErr = reader.Read()
+				reader.Close()
+			case "*.gff3":
+				reader := NewGFFReader(tmpfile.Name())
+				_, readErr = reader.Read()
+				reader.Close()
+			case "*.bed":
+				reader := NewBEDReader(tmpfile.Name())
+				for readErr == nil {
+					_, readErr = reader.Read()
+				}
+				reader.Close()
+			}
+			if readErr == nil || !strings.HasPrefix(readErr.Error(), tmpfile.Name()+":") || !strings.HasSuffix(readErr.Error(), "2: bufio.Scanner: token too long") {
+				t.Errorf("Error: Read() of %s returned %v, expected an error naming the file and line 2", pattern, readErr)
+			}
+		}
+	}
+}
+
+func TestReadersMissingTerminator(t *testing.T) {
+	files := map[string]string{
+		"*.gbff": "LOCUS       OPEN                      10 bp    DNA     linear   SYN 01-JAN-2000\nORIGIN\n        1 acgtacgtac\n" +
+			"LOCUS       NEXT                      10 bp    DNA     linear   SYN 01-JAN-2000\nORIGIN\n        1 acgtacgtac\n//\n",
+		"*.embl": "ID   OPEN; SV 1; linear; genomic DNA; STD; SYN; 4 BP.\nSQ   Sequence 4 BP;\n     acgt                                                               4\n" +
+			"ID   NEXT; SV 1; linear; genomic DNA; STD; SYN; 4 BP.\nSQ   Sequence 4 BP;\n     acgt                                                               4\n//\n",
+	}
+	for pattern, text := range files {
+		if tmpfile, err := os.CreateTemp("", pattern); parseio.ExitOnError(err) {
+			defer os.Remove(tmpfile.Name())
+			_, err = tmpfile.WriteString(text)
+			parseio.ExitOnError(err)
+			parseio.ExitOnError(tmpfile.Close())
+
+			// read returns the name of the next record, or the error of the reader
+			var read func() (string, error)
+			var errs func() []*ParseError
+			for _, mode := range []ParseMode{Strict, Lenient} {
+				switch pattern {
+				case "*.gbff":
+					reader := NewGenBankReader(tmpfile.Name())
+					defer reader.Close()
+					reader.SetMode(mode)
+					read = func() (string, error) {
+						entry, err := reader.Read()
+						if err != nil {
+							return "", err
+						}
+						return entry.Locus, nil
+					}
+					errs = reader.Errors
+				case "*.embl":
+					reader := NewEMBLReader(tmpfile.Name())
+					defer reader.Close()
+					reader.SetMode(mode)
+					read = func() (string, error) {
+						entry, err := reader.Read()
+						if err != nil {
+							return "", err
+						}
+						return entry.ID, nil
+					}
+					errs = reader.Errors
+				}
+
+				// The record runs into the header on line 4 without its // line
+				header := strings.Split(text, "\n")[3]
+				expected := ParseError{Filename: tmpfile.Name(), Line: 4, Record: "OPEN", Text: header, Err: errTruncated}
+				if mode == Strict {
+					_, err := read()
+					var parseErr *ParseError
+					if !errors.As(err, &parseErr) || *parseErr != expected {
+						t.Errorf("Error: strict Read() of %s returned %v, expected: %v", pattern, err, &expected)
+					}
+					continue
+				}
+				var names []string
+				for name, err := read(); err != io.EOF; name, err = read() {
+					if err != nil {
+						t.Fatalf("Error: lenient Read() of %s returned %v", pattern, err)
+					}
+					names = append(names, name)
+				}
+				if strings.Join(names, ",") != "NEXT" || len(errs()) != 1 || *errs()[0] != expected {
+					t.Errorf("Error: lenient Read() of %s returned %v with errors %v, expected: NEXT with %v", pattern, names, errs(), &expected)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package annotation
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Record is implemented by the sequence records of every supported format (EMBL, GenBank and
+// UniProt), so that tools can be written once against it. The methods carry a Record prefix
+// because the entry structs already use the plain names for their fields.
+type Record interface {
+	Entry
+	RecordID() string                         // Entry name or locus
+	RecordAccessions() []string               // Accession numbers, primary accession first
+	RecordDescription() string                // One line description of the sequence
+	RecordOrganism() string                   // Scientific name of the source organism
+	RecordTaxonID() int                       // NCBI taxonomy identifier, 0 if unknown
+	RecordSequence() string                   // Nucleotide or protein sequence
+	RecordFeatures() ([]RecordFeature, error) // Features with parsed locations
+	RecordCrossReferences() []CrossReference  // Links to other databases
+}
+
+// RecordFeature is a feature of a Record with its location parsed. Qualifier keys follow the
+// Feature convention of a leading slash and newline separated repeated values.
+type RecordFeature struct {
+	Key        string
+	Location   Location
+	Qualifiers map[string]string
+}
+
+// CrossReference links a record to an entry of another database.
+type CrossReference struct {
+	Database string
+	ID       string
+}
+
+// RecordID returns the EMBL entry identifier.
+func (e *EMBLEntry) RecordID() string {
+	return e.ID
+}
+
+// RecordAccessions returns the accession numbers of the EMBL entry.
+func (e *EMBLEntry) RecordAccessions() []string {
+	return e.Accession
+}
+
+// RecordDescription returns the DE line of the EMBL entry.
+func (e *EMBLEntry) RecordDescription() string {
+	return e.Description
+}
+
+// RecordOrganism returns the scientific name from the OS line without the common name.
+func (e *EMBLEntry) RecordOrganism() string {
+	if i := strings.Index(e.Source, " ("); i > 0 && strings.HasSuffix(e.Source, ")") {
+		return e.Source[:i]
+	}
+	return e.Source
+}
+
+// RecordTaxonID returns the taxon of the source feature.
+func (e *EMBLEntry) RecordTaxonID() int {
+	return featureTaxonID(e.Features)
+}
+
+// RecordSequence returns the sequence of the EMBL entry.
+func (e *EMBLEntry) RecordSequence() string {
+	return e.Sequence
+}
+
+// RecordFeatures returns the feature table of the EMBL entry with parsed locations.
+func (e *EMBLEntry) RecordFeatures() ([]RecordFeature, error) {
+	return recordFeatures(e.Features)
+}
+
+// RecordCrossReferences returns the project and the DR lines of the EMBL entry.
+func (e *EMBLEntry) RecordCrossReferences() []CrossReference {
+	var xrefs []CrossReference
+	if project := strings.TrimPrefix(e.Project, "Project:"); project != "" {
+		xrefs = append(xrefs, CrossReference{Database: "BioProject", ID: project})
+	}
+	for _, xref := range e.CrossReferences {
+		if database, id, found := strings.Cut(xref, ";"); found {
+			id, _, _ = strings.Cut(id, ";")
+			xrefs = append(xrefs, CrossReference{Database: strings.TrimSpace(database), ID: strings.TrimSpace(id)})
+		}
+	}
+	return xrefs
+}
+
+// RecordID returns the locus name of the GenBank record.
+func (e *GenBankEntry) RecordID() string {
+	return e.Locus
+}
+
+// RecordAccessions returns the accession numbers of the GenBank record.
+func (e *GenBankEntry) RecordAccessions() []string {
+	return e.Accession
+}
+
+// RecordDescription returns the DEFINITION of the GenBank record.
+func (e *GenBankEntry) RecordDescription() string {
+	return e.Definition
+}
+
+// RecordOrganism returns the ORGANISM of the GenBank record.
+func (e *GenBankEntry) RecordOrganism() string {
+	return e.Organism
+}
+
+// RecordTaxonID returns the taxon of the source feature.
+func (e *GenBankEntry) RecordTaxonID() int {
+	return featureTaxonID(e.Features)
+}
+
+// RecordSequence returns the sequence of the GenBank record.
+func (e *GenBankEntry) RecordSequence() string {
+	return e.Sequence
+}
+
+// RecordFeatures returns the feature table of the GenBank record with parsed locations.
+func (e *GenBankEntry) RecordFeatures() ([]RecordFeature, error) {
+	return recordFeatures(e.Features)
+}
+
+// RecordCrossReferences returns the DBLINK identifiers of the GenBank record.
+func (e *GenBankEntry) RecordCrossReferences() []CrossReference {
+	var xrefs []CrossReference
+	for _, link := range e.DBLink {
+		database, ids, found := strings.Cut(link, ":")
+		if !found {
+			continue
+		}
+		for _, id := range strings.Split(ids, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				xrefs = append(xrefs, CrossReference{Database: strings.TrimSpace(database), ID: id})
+			}
+		}
+	}
+	return xrefs
+}
+
+// recordFeatures parses the locations of an INSDC feature table.
+func recordFeatures(features []Feature) ([]RecordFeature, error) {
+	records := make([]RecordFeature, 0, len(features))
+	for _, feature := range features {
+		loc, err := feature.ParseLocation()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, RecordFeature{Key: feature.Key, Location: loc, Qualifiers: feature.Qualifiers})
+	}
+	return records, nil
+}
+
+// featureTaxonID returns the NCBI taxon of the /db_xref="taxon:9606" qualifier of the source
+// feature, or 0 if there is none.
+func featureTaxonID(features []Feature) int {
+	for _, feature := range features {
+		if feature.Key != "source" {
+			continue
+		}
+		for _, xref := range feature.Values("/db_xref") {
+			if taxon, found := strings.CutPrefix(xref, "taxon:"); found {
+				if id, err := strconv.Atoi(taxon); err == nil {
+					return id
+				}
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package annotation
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecord(t *testing.T) {
+	reader := NewGenBankReader("testdata/human.biological_region.gbff.gz")
+	defer reader.Close()
+	entry, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+
+	for _, record := range []Record{entry, GenBankToEMBL(entry)} {
+		if record.RecordID() != "NG_055818" || !reflect.DeepEqual(record.RecordAccessions(), []string{"NG_055818"}) {
+			t.Errorf("Error: RecordID() = %s, RecordAccessions() = %v", record.RecordID(), record.RecordAccessions())
+		}
+		if record.RecordOrganism() != "Homo sapiens" || record.RecordTaxonID() != 9606 {
+			t.Errorf("Error: RecordOrganism() = %s, RecordTaxonID() = %d", record.RecordOrganism(), record.RecordTaxonID())
+		}
+		if xrefs := record.RecordCrossReferences(); !reflect.DeepEqual(xrefs, []CrossReference{{Database: "BioProject", ID: "PRJNA343958"}}) {
+			t.Errorf("Error: RecordCrossReferences() = %+v", xrefs)
+		}
+		features, err := record.RecordFeatures()
+		if err != nil || len(features) != 7 || features[2].Key != "regulatory" || features[2].Location.Start() != 101 || features[2].Location.End() != 555 {
+			t.Errorf("Error: RecordFeatures() = %+v, %v", features, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package annotation
+
+// complementBase maps IUPAC nucleotide codes, in either case, to their complement.
+var complementBase = func() [256]byte {
+	var table [256]byte
+	for i := range table {
+		table[i] = byte(i)
+	}
+	pairs := []string{"AT", "CG", "RY", "KM", "BV", "DH", "SS", "WW", "NN"}
+	for _, pair := range pairs {
+		for _, p := range []string{pair, pair[1:] + pair[:1]} {
+			table[p[0]] = p[1]
+			table[p[0]+'a'-'A'] = p[1] + 'a' - 'A'
+		}
+	}
+	table['U'], table['u'] = 'A', 'a'
+	return table
+}()
+
+// ReverseComplement returns the reverse complement of a nucleotide sequence, preserving case
+// and IUPAC ambiguity codes.
+func ReverseComplement(seq string) string {
+	ans := make([]byte, len(seq))
+	for i := 0; i < len(seq); i++ {
+		ans[len(seq)-1-i] = complementBase[seq[i]]
+	}
+	return string(ans)
+}
@@ -0,0 +1,170 @@
+package annotation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/protein"
+)
+
+// translExcept matches each (pos:location,aa:name) group of a /transl_except qualifier.
+var translExcept = regexp.MustCompile(`\(\s*pos:\s*([^,]+?)\s*,\s*aa:\s*([A-Za-z]+)\s*\)`)
+
+// exceptAminoAcids maps the names /transl_except allows besides the three letter codes.
+var exceptAminoAcids = map[string]protein.Protein{"TERM": protein.Stop, "OTHER": protein.Xaa}
+
+// TranslateCDS translates a CDS feature of sequence into protein. The genetic code is taken from
+// /transl_table (default 1), the reading frame from /codon_start and individual codons are
+// overridden by /transl_except. A complete CDS starts with Met whenever its first codon is an
+// initiation codon of the table, and its terminal stop codon is dropped so that the result can
+// be compared with /translation. Internal stop codons are kept as protein.Stop.
+func TranslateCDS(sequence string, f Feature) ([]protein.Protein, error) {
+	loc, err := f.ParseLocation()
+	if err != nil {
+		return nil, err
+	}
+	bases, err := loc.Extract(sequence)
+	if err != nil {
+		return nil, fmt.Errorf("feature %s %s: %v", f.Key, f.Location, err)
+	}
+
+	table, codonStart := 1, 1
+	if value, ok := f.Qualifiers["/transl_table"]; ok {
+		if table, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+			return nil, fmt.Errorf("feature %s %s: invalid /transl_table %q", f.Key, f.Location, value)
+		}
+	}
+	if value, ok := f.Qualifiers["/codon_start"]; ok {
+		if codonStart, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || codonStart < 1 || codonStart > 3 {
+			return nil, fmt.Errorf("feature %s %s: invalid /codon_start %q", f.Key, f.Location, value)
+		}
+	}
+	code, err := protein.LookupGeneticCode(table)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) < codonStart-1 {
+		return nil, fmt.Errorf("feature %s %s: /codon_start %d is beyond the feature", f.Key, f.Location, codonStart)
+	}
+	bases = bases[codonStart-1:]
+	ans := code.Translate(bases)
+
+	if len(ans) > 0 && codonStart == 1 && !fivePrimePartial(loc) && code.IsStart(bases[:3]) {
+		ans[0] = protein.Met
+	}
+	if err = applyTranslExcept(ans, loc, codonStart, f); err != nil {
+		return nil, err
+	}
+	if len(ans) > 0 && ans[len(ans)-1] == protein.Stop {
+		ans = ans[:len(ans)-1]
+	}
+	return ans, nil
+}
+
+// CheckTranslation translates a CDS feature and compares the result with its /translation
+// qualifier, returning an error that describes the first difference.
+func CheckTranslation(sequence string, f Feature) error {
+	expected, ok := f.Qualifiers["/translation"]
+	if !ok {
+		return fmt.Errorf("feature %s %s has no /translation qualifier", f.Key, f.Location)
+	}
+	expected = strings.Join(strings.Fields(expected), "")
+	translated, err := TranslateCDS(sequence, f)
+	if err != nil {
+		return err
+	}
+	actual := protein.ToString(translated)
+	if actual == expected {
+		return nil
+	}
+	for i := 0; i < len(actual) && i < len(expected); i++ {
+		if actual[i] != expected[i] {
+			return fmt.Errorf("feature %s %s: translation differs at residue %d: %c != %c", f.Key, f.Location, i+1, actual[i], expected[i])
+		}
+	}
+	return fmt.Errorf("feature %s %s: translation length %d != /translation length %d", f.Key, f.Location, len(actual), len(expected))
+}
+
+// Translate translates a CDS feature belonging to the EMBLEntry.
+func (e *EMBLEntry) Translate(f Feature) ([]protein.Protein, error) {
+	return TranslateCDS(e.Sequence, f)
+}
+
+// Translate translates a CDS feature belonging to the GenBankEntry.
+func (e *GenBankEntry) Translate(f GenBankFeature) ([]protein.Protein, error) {
+	return TranslateCDS(e.Sequence, f)
+}
+
+// applyTranslExcept overrides the residues named by the /transl_except qualifier of f.
+func applyTranslExcept(residues []protein.Protein, cds Location, codonStart int, f Feature) error {
+	value, ok := f.Qualifiers["/transl_except"]
+	if !ok {
+		return nil
+	}
+	matches := translExcept.FindAllStringSubmatch(value, -1)
+	if len(matches) == 0 {
+		return fmt.Errorf("feature %s %s: invalid /transl_except %q", f.Key, f.Location, value)
+	}
+	for _, match := range matches {
+		aa, ok := exceptAminoAcid(match[2])
+		if !ok {
+			return fmt.Errorf("feature %s %s: unknown amino acid %q in /transl_except", f.Key, f.Location, match[2])
+		}
+		pos, err := ParseLocation(match[1])
+		if err != nil {
+			return fmt.Errorf("feature %s %s: %v", f.Key, f.Location, err)
+		}
+		first := pos.Spans[0]
+		base := first.Start
+		if first.Complement {
+			base = first.End
+		}
+		offset := cds.Offset(base) - (codonStart - 1)
+		if offset < 0 {
+			return fmt.Errorf("feature %s %s: /transl_except position %s is outside of the CDS", f.Key, f.Location, match[1])
+		}
+		switch index := offset / 3; {
+		case index < len(residues):
+			residues[index] = aa
+		case index == len(residues) && aa == protein.Stop:
+			// An incomplete stop codon completed by the poly(A) tail, which is dropped anyway
+		default:
+			return fmt.Errorf("feature %s %s: /transl_except position %s is outside of the CDS", f.Key, f.Location, match[1])
+		}
+	}
+	return nil
+}
+
+// exceptAminoAcid returns the amino acid named in /transl_except, which takes the INSDC three
+// letter codes, exactly as written, of the amino acids with a one letter code, TERM and OTHER.
+// Full names and the codes of unusual amino acids such as Orn are rejected.
+func exceptAminoAcid(name string) (protein.Protein, bool) {
+	if aa, ok := exceptAminoAcids[name]; ok {
+		return aa, true
+	}
+	aa, err := protein.ParseName(name)
+	if err != nil || aa.Abbreviation() != name {
+		return protein.Unknown, false
+	}
+	switch protein.ToString([]protein.Protein{aa}) {
+	case "*":
+		return protein.Unknown, false // Stop codons are written TERM
+	case "X":
+		return aa, aa == protein.Xaa
+	}
+	return aa, true
+}
+
+// fivePrimePartial reports whether the 5' end of the location is marked as partial.
+func fivePrimePartial(loc Location) bool {
+	if len(loc.Spans) == 0 {
+		return false
+	}
+	first := loc.Spans[0]
+	if first.Complement {
+		return first.PartialEnd
+	}
+	return first.PartialStart
+}
@@ -0,0 +1,57 @@
+package annotation
+
+import (
+	"testing"
+
+	"gopher-proteinlab/protein"
+)
+
+func TestTranslateCDS(t *testing.T) {
+	// TTG start, Ala, TGA recoded as Sec, Trp, stop
+	forward := "ccTTGGCCTGATGGTAGcc"
+	tests := []struct {
+		sequence   string
+		feature    Feature
+		expected   string
+		translated bool
+	}{
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{}}, "MA*W", true},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{
+			"/transl_except": "(pos:9..11,aa:Sec)",
+			"/translation":   "MAUW",
+		}}, "MAUW", true},
+		{forward, Feature{Key: "CDS", Location: "<3..17", Qualifiers: map[string]string{}}, "LA*W", true},
+		{forward, Feature{Key: "CDS", Location: "2..17", Qualifiers: map[string]string{"/codon_start": "2"}}, "LA*W", true},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{"/transl_table": "2"}}, "LAWW", true},
+		{ReverseComplement(forward), Feature{Key: "CDS", Location: "complement(join(3..8,9..17))", Qualifiers: map[string]string{
+			"/transl_except": "(pos:complement(9..11),aa:Sec)",
+		}}, "MAUW", true},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{"/transl_table": "7"}}, "", false},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{"/transl_except": "(pos:30..32,aa:Sec)"}}, "", false},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{"/transl_except": "(pos:9..11,aa:selenocysteine)"}}, "", false},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{"/transl_except": "(pos:9..11,aa:Orn)"}}, "", false},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{"/transl_except": "(pos:9..11,aa:SEC)"}}, "", false},
+		{forward, Feature{Key: "CDS", Location: "3..17", Qualifiers: map[string]string{"/transl_except": "(pos:9..11,aa:OTHER)"}}, "MAXW", true},
+	}
+	for _, test := range tests {
+		translated, err := TranslateCDS(test.sequence, test.feature)
+		if (err == nil) != test.translated {
+			t.Errorf("Error: TranslateCDS(%s) returned error %v", test.feature.Location, err)
+		}
+		if protein.ToString(translated) != test.expected {
+			t.Errorf("Error: TranslateCDS(%s) = %s, expected: %s", test.feature.Location, protein.ToString(translated), test.expected)
+		}
+	}
+}
+
+func TestCheckTranslation(t *testing.T) {
+	entry := &GenBankEntry{Sequence: "ATGGCCTGGTAA"}
+	feature := Feature{Key: "CDS", Location: "1..12", Qualifiers: map[string]string{"/translation": "MAW"}}
+	if err := CheckTranslation(entry.Sequence, feature); err != nil {
+		t.Errorf("Error: CheckTranslation() returned %v", err)
+	}
+	feature.Qualifiers["/translation"] = "MAY"
+	if err := CheckTranslation(entry.Sequence, feature); err == nil {
+		t.Errorf("Error: CheckTranslation() expected a mismatch at residue 3")
+	}
+}
@@ -0,0 +1,312 @@
+package annotation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Severity grades a problem found by the validator.
+type Severity int
+
+const (
+	Warning Severity = iota // The record is usable but does not follow the INSDC conventions
+	Error                   // The record contradicts itself, e.g. a location beyond the sequence
+)
+
+// String returns warning or error.
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// ValidationIssue is one problem found in a record. Line is the line of the file the problem was
+// found on, or 0 when the record was not read from a flat file.
+type ValidationIssue struct {
+	Severity Severity
+	Record   string // Locus name or ID of the record
+	Line     int
+	Feature  string // Key and location of the feature, empty for problems of the whole record
+	Message  string
+}
+
+// String formats the issue as "line: severity: record feature: message", leaving out the line
+// when it is unknown.
+func (i ValidationIssue) String() string {
+	context := i.Record
+	if i.Feature != "" {
+		context += " " + i.Feature
+	}
+	if i.Line > 0 {
+		return fmt.Sprintf("%d: %s: %s: %s", i.Line, i.Severity, context, i.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Severity, context, i.Message)
+}
+
+// featureKeys lists the feature keys of the INSDC feature table definition. Deprecated keys that
+// older records still use map to true.
+var featureKeys = map[string]bool{
+	"assembly_gap": false, "C_region": false, "CDS": false, "centromere": false, "D-loop": false,
+	"D_segment": false, "exon": false, "gap": false, "gene": false, "iDNA": false, "intron": false,
+	"J_segment": false, "mat_peptide": false, "misc_binding": false, "misc_difference": false,
+	"misc_feature": false, "misc_recomb": false, "misc_RNA": false, "misc_structure": false,
+	"mobile_element": false, "modified_base": false, "mRNA": false, "ncRNA": false, "N_region": false,
+	"old_sequence": false, "operon": false, "oriT": false, "polyA_site": false, "precursor_RNA": false,
+	"prim_transcript": false, "primer_bind": false, "propeptide": false, "protein_bind": false,
+	"regulatory": false, "repeat_region": false, "rep_origin": false, "rRNA": false, "S_region": false,
+	"sig_peptide": false, "source": false, "stem_loop": false, "STS": false, "telomere": false,
+	"tmRNA": false, "transit_peptide": false, "tRNA": false, "unsure": false, "V_region": false,
+	"V_segment": false, "variation": false, "3'UTR": false, "5'UTR": false,
+	"-10_signal": true, "-35_signal": true, "3'clip": true, "5'clip": true, "attenuator": true,
+	"CAAT_signal": true, "conflict": true, "enhancer": true, "GC_signal": true, "LTR": true,
+	"misc_signal": true, "mutation": true, "polyA_signal": true, "promoter": true, "RBS": true,
+	"repeat_unit": true, "satellite": true, "TATA_signal": true, "terminator": true, "allele": true,
+}
+
+// proteinFeatureKeys lists the additional feature keys of GenPept protein records.
+var proteinFeatureKeys = map[string]bool{
+	"Protein": true, "Region": true, "Site": true, "Bond": true, "SecStr": true, "Het": true,
+	"Precursor": true, "proprotein": true, "NonStdResidue": true,
+}
+
+// featureQualifiers lists the qualifiers of the INSDC feature table definition, together with the
+// NCBI qualifiers found in RefSeq and GenPept records.
+var featureQualifiers = map[string]bool{
+	"/allele": true, "/altitude": true, "/anticodon": true, "/artificial_location": true,
+	"/bio_material": true, "/bound_moiety": true, "/cell_line": true, "/cell_type": true,
+	"/chromosome": true, "/circular_RNA": true, "/citation": true, "/clone": true, "/clone_lib": true,
+	"/codon_start": true, "/collected_by": true, "/collection_date": true, "/compare": true,
+	"/country": true, "/cultivar": true, "/culture_collection": true, "/db_xref": true,
+	"/dev_stage": true, "/direction": true, "/EC_number": true, "/ecotype": true,
+	"/environmental_sample": true, "/estimated_length": true, "/exception": true,
+	"/experiment": true, "/focus": true, "/frequency": true, "/function": true, "/gap_type": true,
+	"/gene": true, "/gene_synonym": true, "/geo_loc_name": true, "/germline": true,
+	"/haplogroup": true, "/haplotype": true, "/host": true, "/identified_by": true,
+	"/inference": true, "/isolate": true, "/isolation_source": true, "/lab_host": true,
+	"/lat_lon": true, "/linkage_evidence": true, "/locus_tag": true, "/macronuclear": true,
+	"/map": true, "/mating_type": true, "/metagenome_source": true, "/mobile_element_type": true,
+	"/mod_base": true, "/mol_type": true, "/ncRNA_class": true, "/note": true, "/number": true,
+	"/old_locus_tag": true, "/operon": true, "/organelle": true, "/organism": true,
+	"/partial": true, "/PCR_conditions": true, "/PCR_primers": true, "/phenotype": true,
+	"/plasmid": true, "/pop_variant": true, "/product": true, "/protein_id": true,
+	"/proviral": true, "/pseudo": true, "/pseudogene": true, "/rearranged": true,
+	"/recombination_class": true, "/regulatory_class": true, "/replace": true,
+	"/ribosomal_slippage": true, "/rpt_family": true, "/rpt_type": true, "/rpt_unit_range": true,
+	"/rpt_unit_seq": true, "/satellite": true, "/segment": true, "/serotype": true,
+	"/serovar": true, "/sex": true, "/specimen_voucher": true, "/standard_name": true,
+	"/strain": true, "/sub_clone": true, "/sub_species": true, "/sub_strain": true,
+	"/submitter_seqid": true, "/tag_peptide": true, "/tissue_lib": true, "/tissue_type": true,
+	"/trans_splicing": true, "/transgenic": true, "/translation": true, "/transl_except": true,
+	"/transl_table": true, "/type_material": true, "/variety": true,
+	// NCBI extensions
+	"/bond_type": true, "/calculated_mol_wt": true, "/coded_by": true, "/cyt_map": true,
+	"/gen_map": true, "/GO_component": true, "/GO_function": true, "/GO_process": true,
+	"/peptide": true, "/rad_map": true, "/region_name": true, "/site_type": true,
+	"/transcript_id": true, "/UniProtKB_evidence": true,
+}
+
+// ValidateGenBank checks a GenBank record for contradictions between its LOCUS line, feature
+// table and sequence, and for feature keys and qualifiers outside the INSDC vocabulary. The
+// issues are reported with the line numbers of lines, which may be empty.
+func ValidateGenBank(entry *GenBankEntry, lines RecordLines) []ValidationIssue {
+	v := validator{record: entry.Locus, length: entry.Length, sequence: entry.Sequence, lines: lines,
+		protein: entry.IsProtein(), assembled: entry.Contig != ""}
+	v.validate(entry.Features)
+	return v.issues
+}
+
+// ValidateEMBL checks an EMBL entry like ValidateGenBank checks a GenBank record.
+func ValidateEMBL(entry *EMBLEntry, lines RecordLines) []ValidationIssue {
+	v := validator{record: entry.ID, length: entry.Length, sequence: entry.Sequence, lines: lines,
+		assembled: entry.Contig != ""}
+	v.validate(entry.Features)
+	return v.issues
+}
+
+// ValidateGenBankFile validates every record of a GenBank file. The error is only set when the
+// file cannot be parsed.
+func ValidateGenBankFile(filename string) ([]ValidationIssue, error) {
+	reader := NewGenBankReader(filename)
+	defer reader.Close()
+
+	var issues []ValidationIssue
+	for {
+		entry, err := reader.Read()
+		if err == io.EOF {
+			return issues, nil
+		}
+		if err != nil {
+			return issues, err
+		}
+		issues = append(issues, ValidateGenBank(entry, reader.Lines())...)
+	}
+}
+
+// ValidateEMBLFile validates every entry of an EMBL file. The error is only set when the file
+// cannot be parsed.
+func ValidateEMBLFile(filename string) ([]ValidationIssue, error) {
+	reader := NewEMBLReader(filename)
+	defer reader.Close()
+
+	var issues []ValidationIssue
+	for {
+		entry, err := reader.Read()
+		if err == io.EOF {
+			return issues, nil
+		}
+		if err != nil {
+			return issues, err
+		}
+		issues = append(issues, ValidateEMBL(entry, reader.Lines())...)
+	}
+}
+
+// validator collects the issues of one record.
+type validator struct {
+	record    string
+	length    int
+	sequence  string
+	protein   bool
+	assembled bool // CON records describe their sequence with a CONTIG line instead
+	lines     RecordLines
+	issues    []ValidationIssue
+}
+
+// report adds an issue.
+func (v *validator) report(severity Severity, line int, feature string, format string, args ...interface{}) {
+	v.issues = append(v.issues, ValidationIssue{
+		Severity: severity,
+		Record:   v.record,
+		Line:     line,
+		Feature:  feature,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// validate checks the sequence and then every feature.
+func (v *validator) validate(features []Feature) {
+	line := v.lines.Sequence
+	if line == 0 {
+		line = v.lines.Record
+	}
+	switch {
+	case v.sequence == "" && !v.assembled && v.length > 0:
+		v.report(Warning, v.lines.Record, "", "record of length %d has no sequence", v.length)
+	case v.sequence != "" && v.length > 0 && len(v.sequence) != v.length:
+		v.report(Error, line, "", "sequence length %d disagrees with the declared length %d", len(v.sequence), v.length)
+	}
+	if i := strings.IndexFunc(v.sequence, v.invalidResidue); i >= 0 {
+		v.report(Error, line, "", "invalid character %q at position %d of the sequence", v.sequence[i], i+1)
+	}
+
+	for i, f := range features {
+		v.validateFeature(f, v.lines.Feature(i))
+	}
+}
+
+// sequenceLength returns the declared length of the record, or the length of its sequence when
+// no length was declared, as on a bare EMBL ID line. It is 0 when neither is known.
+func (v *validator) sequenceLength() int {
+	if v.length > 0 {
+		return v.length
+	}
+	return len(v.sequence)
+}
+
+// invalidResidue reports whether r is neither an IUPAC nucleotide nor, for protein records, an
+// amino acid code.
+func (v *validator) invalidResidue(r rune) bool {
+	if v.protein {
+		return !strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZ*-", r) && !strings.ContainsRune("abcdefghijklmnopqrstuvwxyz", r)
+	}
+	return !strings.ContainsRune("acgtumrwsykvhdbnACGTUMRWSYKVHDBN", r)
+}
+
+// validateFeature checks the key, qualifiers and location of a feature, and the reading frame and
+// translation of a CDS.
+func (v *validator) validateFeature(f Feature, line int) {
+	name := f.Key + " " + f.Location
+	if deprecated, ok := featureKeys[f.Key]; !ok && !(v.protein && proteinFeatureKeys[f.Key]) {
+		v.report(Warning, line, name, "unknown feature key %s", f.Key)
+	} else if deprecated {
+		v.report(Warning, line, name, "feature key %s is deprecated", f.Key)
+	}
+
+	qualifiers := make([]string, 0, len(f.Qualifiers))
+	for key := range f.Qualifiers {
+		if !featureQualifiers[key] {
+			qualifiers = append(qualifiers, key)
+		}
+	}
+	sort.Strings(qualifiers)
+	for _, key := range qualifiers {
+		v.report(Warning, line, name, "unknown qualifier %s", key)
+	}
+
+	loc, err := ParseLocation(f.Location)
+	if err != nil {
+		v.report(Error, line, name, "invalid location: %v", err)
+		return
+	}
+	local := true
+	for _, span := range loc.Spans {
+		if span.Accession != "" {
+			local = false
+			continue
+		}
+		if length := v.sequenceLength(); span.Start < 1 || (length > 0 && span.End > length) {
+			v.report(Error, line, name, "span %d..%d exceeds the sequence length %d", span.Start, span.End, length)
+			return
+		}
+	}
+
+	_, pseudo := f.Qualifiers["/pseudo"]
+	if _, ok := f.Qualifiers["/pseudogene"]; ok {
+		pseudo = true
+	}
+	if f.Key != "CDS" || v.protein || pseudo || !local {
+		return
+	}
+	partial := false
+	for _, span := range loc.Spans {
+		partial = partial || span.PartialStart || span.PartialEnd
+	}
+	_, slippage := f.Qualifiers["/ribosomal_slippage"]
+	if loc.Len()%3 != 0 && !partial && !slippage && !stopCompletedByPolyA(f, loc) {
+		v.report(Error, line, name, "CDS length %d is not a multiple of three", loc.Len())
+	}
+	if _, ok := f.Qualifiers["/translation"]; ok && v.sequence != "" {
+		if err = CheckTranslation(v.sequence, f); err != nil {
+			// Annotated exceptions such as RNA editing explain a differing translation
+			severity := Error
+			if _, ok := f.Qualifiers["/exception"]; ok {
+				severity = Warning
+			}
+			v.report(severity, line, name, "/translation mismatch: %s", strings.TrimPrefix(err.Error(), "feature "+name+": "))
+		}
+	}
+}
+
+// stopCompletedByPolyA reports whether a /transl_except with TERM covers the incomplete last codon
+// of the CDS, a stop codon completed by polyadenylation of the mRNA as in vertebrate mitochondrial
+// genomes, e.g. (pos:4261..4262,aa:TERM).
+func stopCompletedByPolyA(f Feature, cds Location) bool {
+	for _, match := range translExcept.FindAllStringSubmatch(f.Qualifiers["/transl_except"], -1) {
+		pos, err := ParseLocation(match[1])
+		if err != nil || match[2] != "TERM" || pos.Len() != cds.Len()%3 {
+			continue
+		}
+		first := pos.Spans[0]
+		base := first.Start
+		if first.Complement {
+			base = first.End
+		}
+		if offset := cds.Offset(base); offset >= 0 && offset+pos.Len() == cds.Len() {
+			return true
+		}
+	}
+	return false
+}
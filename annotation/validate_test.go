@@ -0,0 +1,141 @@
+package annotation
+
+import (
+	"bufio"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+// brokenGenBank has a sequence shorter than its LOCUS line, a feature beyond the sequence, a CDS
+// whose length is not a multiple of three, a wrong /translation and an unknown key and qualifier.
+const brokenGenBank = `LOCUS       BROKEN                    40 bp    DNA     linear   SYN 01-JAN-2000
+DEFINITION  Broken test record.
+ACCESSION   BROKEN
+FEATURES             Location/Qualifiers
+     source          1..36
+                     /organism="synthetic construct"
+                     /mol_type="other DNA"
+     CDS             1..9
+                     /translation="MKL"
+     CDS             10..17
+     my_feature      20..50
+                     /colour="red"
+ORIGIN
+        1 atgaaatagg gggggggggg gggggggggg gggggg
+//
+`
+
+func TestValidateGenBank(t *testing.T) {
+	if tmpfile, err := os.CreateTemp("", "*.gbff"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.WriteString(brokenGenBank)
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+
+		issues, err := ValidateGenBankFile(tmpfile.Name())
+		if err != nil {
+			t.Fatalf("ValidateGenBankFile failed: %v", err)
+		}
+		expected := []string{
+			"13: error: BROKEN: sequence length 36 disagrees with the declared length 40",
+			"8: error: BROKEN CDS 1..9: /translation mismatch: translation length 2 != /translation length 3",
+			"10: error: BROKEN CDS 10..17: CDS length 8 is not a multiple of three",
+			"11: warning: BROKEN my_feature 20..50: unknown feature key my_feature",
+			"11: warning: BROKEN my_feature 20..50: unknown qualifier /colour",
+			"11: error: BROKEN my_feature 20..50: span 20..50 exceeds the sequence length 40",
+		}
+		var actual []string
+		for _, issue := range issues {
+			actual = append(actual, issue.String())
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("Error: ValidateGenBankFile() =\n%s\nexpected:\n%s", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+		}
+	}
+
+	// Features beyond the sequence are errors even for records that were not read from a file
+	entry := &GenBankEntry{Locus: "SHORT", Length: 10, Sequence: "acgtacgtac", Features: []Feature{{Key: "gene", Location: "5..12"}}}
+	issues := ValidateGenBank(entry, RecordLines{})
+	if len(issues) != 1 || issues[0].Severity != Error || issues[0].String() != "error: SHORT gene 5..12: span 5..12 exceeds the sequence length 10" {
+		t.Errorf("Error: ValidateGenBank() = %v", issues)
+	}
+}
+
+func TestValidateEMBL(t *testing.T) {
+	reader := NewGenBankReader("testdata/human.biological_region.gbff.gz")
+	defer reader.Close()
+	entry, err := reader.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if issues := ValidateGenBank(entry, reader.Lines()); len(issues) != 0 {
+		t.Errorf("Error: ValidateGenBank() found issues in a valid record: %v", issues)
+	}
+
+	embl := GenBankToEMBL(entry)
+	embl.Features = append(embl.Features, Feature{Key: "CDS", Location: "join(1..10,", Qualifiers: map[string]string{}})
+	if tmpfile, err := os.CreateTemp("", "*.embl"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		parseio.ExitOnError(tmpfile.Close())
+		parseio.ExitOnError(WriteEMBLFile(tmpfile.Name(), []*EMBLEntry{embl}))
+
+		issues, err := ValidateEMBLFile(tmpfile.Name())
+		if err != nil {
+			t.Fatalf("ValidateEMBLFile failed: %v", err)
+		}
+		if len(issues) != 1 || issues[0].Severity != Error || !strings.Contains(issues[0].Message, "invalid location") {
+			t.Fatalf("Error: ValidateEMBLFile() = %v", issues)
+		}
+		if text, err := os.ReadFile(tmpfile.Name()); parseio.ExitOnError(err) {
+			lines := strings.Split(string(text), "\n")
+			if line := lines[issues[0].Line-1]; !strings.HasPrefix(line, "FT   CDS") {
+				t.Errorf("Error: issue reported on line %d: %q", issues[0].Line, line)
+			}
+		}
+	}
+}
+
+func TestValidateWithoutLength(t *testing.T) {
+	// An EMBL ID line without a length declares none, so the sequence gives the length
+	text := `ID   EMBL000001;
+FT   mRNA            1..20
+FT                   /transcript_id="NM_000001.1"
+FT   gene            15..25
+SQ   Sequence 20 BP;
+     acgtacgtac gtacgtacgt                                                   20
+//
+`
+	scanner := &parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(text))}
+	entry, err := ParseEMBL(scanner)
+	if err != nil || entry.Length != 0 {
+		t.Fatalf("Error: ParseEMBL() = %+v, %v", entry, err)
+	}
+	issues := ValidateEMBL(entry, RecordLines{})
+	if len(issues) != 1 || issues[0].String() != "error: EMBL000001 gene 15..25: span 15..25 exceeds the sequence length 20" {
+		t.Errorf("Error: ValidateEMBL() = %v", issues)
+	}
+
+	entry.Sequence = ""
+	if issues := ValidateEMBL(entry, RecordLines{}); len(issues) != 0 {
+		t.Errorf("Error: ValidateEMBL() of an entry without length and sequence = %v", issues)
+	}
+}
+
+func TestValidatePolyAStop(t *testing.T) {
+	// The TA at the end of the CDS becomes a TAA stop codon once the mRNA is polyadenylated
+	entry := &GenBankEntry{Locus: "MITO", Length: 15, Sequence: "ATGGCCTGGTAcccc", Features: []Feature{
+		{Key: "CDS", Location: "1..11", Qualifiers: map[string]string{"/transl_except": "(pos:10..11,aa:TERM)", "/translation": "MAW"}},
+		{Key: "CDS", Location: "complement(5..15)", Qualifiers: map[string]string{"/transl_except": "(pos:complement(5..5),aa:TERM)"}},
+	}}
+	if issues := ValidateGenBank(entry, RecordLines{}); len(issues) != 1 || issues[0].String() != "error: MITO CDS complement(5..15): CDS length 11 is not a multiple of three" {
+		t.Errorf("Error: ValidateGenBank() = %v, expected only the CDS whose TERM does not cover its last two bases", issues)
+	}
+	entry.Features[1].Qualifiers["/transl_except"] = "(pos:complement(5..6),aa:TERM)"
+	if issues := ValidateGenBank(entry, RecordLines{}); len(issues) != 0 {
+		t.Errorf("Error: ValidateGenBank() = %v, expected no issues", issues)
+	}
+}
@@ -0,0 +1,410 @@
+package annotation
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+)
+
+const (
+	genBankLineWidth = 79 // Maximum width of a GenBank line
+	emblLineWidth    = 80 // Maximum width of an EMBL line
+	featureIndent    = 21 // Column where feature locations and qualifiers start
+)
+
+// unquotedQualifiers lists the qualifiers whose values are written without quotes.
+var unquotedQualifiers = map[string]bool{
+	"/anticodon": true, "/calculated_mol_wt": true, "/citation": true, "/codon_start": true,
+	"/compare": true, "/direction": true, "/estimated_length": true, "/mod_base": true,
+	"/number": true, "/rpt_type": true, "/rpt_unit_range": true, "/tag_peptide": true,
+	"/transl_except": true, "/transl_table": true,
+}
+
+// qualifierRank orders the common qualifiers the way NCBI and ENA write them; the remaining
+// qualifiers follow in alphabetical order with /translation last.
+var qualifierRank = map[string]int{
+	"/organism": 1, "/mol_type": 2, "/gene": 3, "/locus_tag": 4, "/product": 5,
+	"/translation": 100,
+}
+
+// WriteGenBank writes an entry as a GenBank flat file record.
+func WriteGenBank(w io.Writer, entry *GenBankEntry) error {
+	txt := parseio.NewTxtBuilder()
+	width := genBankLineWidth - genBankKeywordWidth
+
+	txt.WriteString(locusLine(entry))
+	writeWrapped(txt, "DEFINITION  ", "            ", entry.Definition, width)
+	writeWrapped(txt, "ACCESSION   ", "            ", strings.Join(entry.Accession, " "), width)
+	writeWrapped(txt, "VERSION     ", "            ", entry.Version, width)
+	writeLines(txt, "DBLINK      ", "            ", entry.DBLink)
+	if entry.DBSource != "" {
+		writeLines(txt, "DBSOURCE    ", "            ", strings.Split(entry.DBSource, "\n"))
+	}
+	writeWrapped(txt, "KEYWORDS    ", "            ", joinList(entry.Keywords, "; ", "."), width)
+	writeWrapped(txt, "SOURCE      ", "            ", entry.Source, width)
+	if entry.Organism != "" || len(entry.Taxonomy) > 0 {
+		writeWrapped(txt, "  ORGANISM  ", "            ", entry.Organism, width)
+		writeWrapped(txt, "            ", "            ", joinList(entry.Taxonomy, "; ", "."), width)
+	}
+	for _, ref := range entry.References {
+		number := ref.Number
+		if ref.Bases != "" {
+			number = fmt.Sprintf("%-3s(%s)", ref.Number, ref.Bases)
+		}
+		writeWrapped(txt, "REFERENCE   ", "            ", number, width)
+		writeWrapped(txt, "  AUTHORS   ", "            ", ref.Authors, width)
+		writeWrapped(txt, "  CONSRTM   ", "            ", ref.Consortium, width)
+		writeWrapped(txt, "  TITLE     ", "            ", ref.Title, width)
+		writeWrapped(txt, "  JOURNAL   ", "            ", ref.Journal, width)
+		writeWrapped(txt, "  MEDLINE   ", "            ", ref.Medline, width)
+		writeWrapped(txt, "   PUBMED   ", "            ", ref.PubMed, width)
+		writeWrapped(txt, "  REMARK    ", "            ", ref.Remark, width)
+	}
+	if entry.Comment != "" {
+		writeLines(txt, "COMMENT     ", "            ", strings.Split(entry.Comment, "\n"))
+	}
+	if entry.Primary != "" {
+		writeLines(txt, "PRIMARY     ", "            ", strings.Split(entry.Primary, "\n"))
+	}
+	txt.WriteString("FEATURES             Location/Qualifiers\n")
+	writeFeatures(txt, "     ", strings.Repeat(" ", featureIndent), entry.Features, genBankLineWidth)
+	if entry.Contig != "" {
+		writeLines(txt, "CONTIG      ", "            ", wrapLocation(entry.Contig, width))
+	}
+	if entry.Sequence != "" {
+		if !entry.IsProtein() {
+			txt.WriteString(baseCountLine(entry.Sequence))
+		}
+		txt.WriteString("ORIGIN\n")
+		for i := 0; i < len(entry.Sequence); i += 60 {
+			txt.WriteString(fmt.Sprintf("%9d", i+1))
+			for _, block := range sequenceBlocks(entry.Sequence, i) {
+				txt.WriteByte(' ')
+				txt.WriteString(block)
+			}
+			txt.WriteByte('\n')
+		}
+	}
+	txt.WriteString("//\n")
+
+	_, err := io.WriteString(w, txt.String())
+	return err
+}
+
+// WriteEMBL writes an entry as an EMBL flat file record.
+func WriteEMBL(w io.Writer, entry *EMBLEntry) error {
+	txt := parseio.NewTxtBuilder()
+	width := emblLineWidth - 5
+
+	length := entry.Length
+	if length == 0 {
+		length = len(entry.Sequence)
+	}
+	txt.WriteString(fmt.Sprintf("ID   %s; SV %s; %s; %s; %s; %s; %d BP.\nXX\n",
+		entry.ID, entry.Version, entry.Topology, entry.MoleculeType, entry.DataClass, entry.Division, length))
+	if len(entry.Accession) > 0 {
+		writeWrapped(txt, "AC   ", "AC   ", strings.Join(entry.Accession, "; ")+";", width)
+		txt.WriteString("XX\n")
+	}
+	if entry.Project != "" {
+		txt.WriteString("PR   " + entry.Project + ";\nXX\n")
+	}
+	if len(entry.Dates) > 0 {
+		writeLines(txt, "DT   ", "DT   ", entry.Dates)
+		txt.WriteString("XX\n")
+	}
+	if entry.Description != "" {
+		writeWrapped(txt, "DE   ", "DE   ", entry.Description, width)
+		txt.WriteString("XX\n")
+	}
+	writeWrapped(txt, "KW   ", "KW   ", joinList(entry.Keywords, "; ", "."), width)
+	txt.WriteString("XX\n")
+	if entry.Source != "" || len(entry.Taxonomy) > 0 {
+		writeWrapped(txt, "OS   ", "OS   ", entry.Source, width)
+		writeWrapped(txt, "OC   ", "OC   ", joinList(entry.Taxonomy, "; ", "."), width)
+		writeWrapped(txt, "OG   ", "OG   ", entry.Organelle, width)
+		txt.WriteString("XX\n")
+	}
+	for _, ref := range entry.References {
+		txt.WriteString("RN   [" + ref.Number + "]\n")
+		writeWrapped(txt, "RC   ", "RC   ", ref.Comment, width)
+		writeWrapped(txt, "RP   ", "RP   ", ref.Positions, width)
+		for _, xref := range ref.CrossReferences {
+			txt.WriteString("RX   " + xref + ".\n")
+		}
+		writeWrapped(txt, "RG   ", "RG   ", ref.Group, width)
+		if ref.Authors != "" {
+			writeWrapped(txt, "RA   ", "RA   ", ref.Authors+";", width)
+		}
+		if ref.Title != "" {
+			writeWrapped(txt, "RT   ", "RT   ", `"`+ref.Title+`";`, width)
+		} else {
+			txt.WriteString("RT   ;\n")
+		}
+		for _, line := range strings.Split(ref.Location, "\n") {
+			writeWrapped(txt, "RL   ", "RL   ", line, width)
+		}
+		txt.WriteString("XX\n")
+	}
+	if len(entry.CrossReferences) > 0 {
+		for _, xref := range entry.CrossReferences {
+			txt.WriteString("DR   " + xref + ".\n")
+		}
+		txt.WriteString("XX\n")
+	}
+	if entry.Comment != "" {
+		writeLines(txt, "CC   ", "CC   ", strings.Split(entry.Comment, "\n"))
+		txt.WriteString("XX\n")
+	}
+	if len(entry.Features) > 0 {
+		txt.WriteString("FH   Key             Location/Qualifiers\nFH\n")
+		writeFeatures(txt, "FT   ", "FT"+strings.Repeat(" ", featureIndent-2), entry.Features, emblLineWidth)
+		txt.WriteString("XX\n")
+	}
+	if entry.Contig != "" {
+		writeLines(txt, "CO   ", "CO   ", wrapLocation(entry.Contig, width))
+		txt.WriteString("XX\n")
+	}
+	if entry.Sequence != "" {
+		counts := countBases(entry.Sequence)
+		txt.WriteString(fmt.Sprintf("SQ   Sequence %d BP; %d A; %d C; %d G; %d T; %d other;\n",
+			len(entry.Sequence), counts[0], counts[1], counts[2], counts[3], counts[4]))
+		for i := 0; i < len(entry.Sequence); i += 60 {
+			end := i + 60
+			if end > len(entry.Sequence) {
+				end = len(entry.Sequence)
+			}
+			txt.WriteString(fmt.Sprintf("     %-65s%10d\n", strings.Join(sequenceBlocks(entry.Sequence, i), " "), end))
+		}
+	}
+	txt.WriteString("//\n")
+
+	_, err := io.WriteString(w, txt.String())
+	return err
+}
+
+// WriteGenBankFile writes entries to a GenBank file, gzipping it when filename ends with .gz.
+func WriteGenBankFile(filename string, entries []*GenBankEntry) error {
+	writer := parseio.NewWriter(filename)
+	for _, entry := range entries {
+		if err := WriteGenBank(writer, entry); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// WriteEMBLFile writes entries to an EMBL file, gzipping it when filename ends with .gz.
+func WriteEMBLFile(filename string, entries []*EMBLEntry) error {
+	writer := parseio.NewWriter(filename)
+	for _, entry := range entries {
+		if err := WriteEMBL(writer, entry); err != nil {
+			writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// locusLine formats the LOCUS line using the column layout of the NCBI release notes.
+func locusLine(entry *GenBankEntry) string {
+	length := entry.Length
+	if length == 0 {
+		length = len(entry.Sequence)
+	}
+	unit, strand, molecule := "bp", "", entry.MoleculeType
+	if entry.IsProtein() {
+		unit, molecule = "aa", ""
+	} else if len(molecule) > 3 && molecule[2] == '-' {
+		strand, molecule = molecule[:3], molecule[3:]
+	}
+	name := fmt.Sprintf("%-16s %11d", entry.Locus, length)
+	if len(name) > 28 {
+		name = fmt.Sprintf("%s %d", entry.Locus, length)
+	}
+	return strings.TrimRight(fmt.Sprintf("LOCUS       %s %s %3s%-6s  %-8s %s %s",
+		name, unit, strand, molecule, entry.Topology, entry.Division, entry.Date), " ") + "\n"
+}
+
+// baseCountLine formats the BASE COUNT line of a nucleotide sequence.
+func baseCountLine(seq string) string {
+	counts := countBases(seq)
+	line := fmt.Sprintf("BASE COUNT  %7d a%7d c%7d g%7d t", counts[0], counts[1], counts[2], counts[3])
+	if counts[4] > 0 {
+		line += fmt.Sprintf("%7d others", counts[4])
+	}
+	return line + "\n"
+}
+
+// countBases counts the A, C, G, T and other bases of a sequence in either case.
+func countBases(seq string) [5]int {
+	var counts [5]int
+	for i := 0; i < len(seq); i++ {
+		switch seq[i] {
+		case 'a', 'A':
+			counts[0]++
+		case 'c', 'C':
+			counts[1]++
+		case 'g', 'G':
+			counts[2]++
+		case 't', 'T':
+			counts[3]++
+		default:
+			counts[4]++
+		}
+	}
+	return counts
+}
+
+// sequenceBlocks splits the 60 residues of seq starting at start into blocks of 10.
+func sequenceBlocks(seq string, start int) []string {
+	var blocks []string
+	for j := start; j < start+60 && j < len(seq); j += 10 {
+		end := j + 10
+		if end > len(seq) {
+			end = len(seq)
+		}
+		blocks = append(blocks, seq[j:end])
+	}
+	return blocks
+}
+
+// writeFeatures writes a feature table. Feature keys follow prefix and locations and qualifiers
+// start at column 21, wrapped to the line width.
+func writeFeatures(txt *parseio.TxtUtility, prefix, indent string, features []Feature, lineWidth int) {
+	width := lineWidth - featureIndent
+	for _, feature := range features {
+		locations := wrapLocation(feature.Location, width)
+		txt.WriteString(fmt.Sprintf("%s%-15s %s\n", prefix, feature.Key, locations[0]))
+		for _, line := range locations[1:] {
+			txt.WriteString(indent + line + "\n")
+		}
+		for _, key := range sortedQualifiers(feature) {
+			for _, value := range feature.Values(key) {
+				for _, line := range wrapQualifier(key, value, width) {
+					txt.WriteString(indent + line + "\n")
+				}
+			}
+		}
+	}
+}
+
+// sortedQualifiers returns the qualifier keys of a feature in the order they are written.
+func sortedQualifiers(feature Feature) []string {
+	keys := make([]string, 0, len(feature.Qualifiers))
+	for key := range feature.Qualifiers {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := rankQualifier(keys[i]), rankQualifier(keys[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// rankQualifier returns the sort rank of a qualifier key.
+func rankQualifier(key string) int {
+	if rank, ok := qualifierRank[key]; ok {
+		return rank
+	}
+	return 50
+}
+
+// wrapQualifier formats a qualifier and wraps it to width. Values are quoted unless the
+// qualifier takes a bare value, and /translation is split anywhere since it has no spaces.
+func wrapQualifier(key, value string, width int) []string {
+	text := key
+	switch {
+	case value == "" && !unquotedQualifiers[key]:
+		// Qualifiers without a value such as /pseudo
+	case unquotedQualifiers[key]:
+		text += "=" + value
+	default:
+		text += `="` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	}
+	if key == "/translation" {
+		return splitWidth(text, width)
+	}
+	return wrapWords(text, width)
+}
+
+// wrapLocation wraps a location after its commas, splitting parts longer than width.
+func wrapLocation(location string, width int) []string {
+	var lines []string
+	var current string
+	for _, part := range strings.SplitAfter(location, ",") {
+		if current != "" && len(current)+len(part) > width {
+			lines = append(lines, current)
+			current = ""
+		}
+		current += part
+		for len(current) > width {
+			lines = append(lines, current[:width])
+			current = current[width:]
+		}
+	}
+	return append(lines, current)
+}
+
+// wrapWords wraps text at spaces so that lines fit in width. Words longer than width, such as
+// long URLs, are kept whole on a longer line, since readers join the lines of a value with a
+// space and a word split at the width would not read back unchanged.
+func wrapWords(text string, width int) []string {
+	var lines []string
+	var current string
+	for i, word := range strings.Split(text, " ") {
+		switch {
+		case i == 0:
+			current = word
+		case len(current)+1+len(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	return append(lines, current)
+}
+
+// splitWidth splits text into lines of at most width characters.
+func splitWidth(text string, width int) []string {
+	var lines []string
+	for len(text) > width {
+		lines = append(lines, text[:width])
+		text = text[width:]
+	}
+	return append(lines, text)
+}
+
+// writeWrapped writes a keyword and its value wrapped to width, skipping empty values.
+func writeWrapped(txt *parseio.TxtUtility, keyword, indent, value string, width int) {
+	if value == "" {
+		return
+	}
+	writeLines(txt, keyword, indent, wrapWords(value, width))
+}
+
+// writeLines writes the first line after keyword and the remaining lines after indent.
+func writeLines(txt *parseio.TxtUtility, keyword, indent string, lines []string) {
+	for i, line := range lines {
+		if i == 0 {
+			txt.WriteString(keyword)
+		} else {
+			txt.WriteString(indent)
+		}
+		txt.WriteString(line)
+		txt.WriteByte('\n')
+	}
+}
+
+// joinList joins items with sep and appends end, returning just end for an empty list.
+func joinList(items []string, sep, end string) string {
+	return strings.Join(items, sep) + end
+}
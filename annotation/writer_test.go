@@ -0,0 +1,175 @@
+package annotation
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+func TestWriteGenBank(t *testing.T) {
+	reader := NewGenBankReader("testdata/human.biological_region.gbff.gz")
+	defer reader.Close()
+
+	for count := 0; ; count++ {
+		entry, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read failed after %d records: %v", count, err)
+		}
+		var text strings.Builder
+		if err = WriteGenBank(&text, entry); err != nil {
+			t.Fatalf("WriteGenBank failed: %v", err)
+		}
+		if count == 0 {
+			expected := "LOCUS       NG_055818                655 bp    DNA     linear   CON 24-SEP-2024\n"
+			if !strings.HasPrefix(text.String(), expected) {
+				t.Errorf("Error: WriteGenBank() LOCUS line = %q, expected: %q", strings.SplitAfter(text.String(), "\n")[0], expected)
+			}
+		}
+		for _, line := range strings.Split(text.String(), "\n") {
+			if len(line) > genBankLineWidth {
+				t.Errorf("Error: %s line exceeds %d columns: %q", entry.Locus, genBankLineWidth, line)
+			}
+		}
+
+		scanner := &parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(text.String()))}
+		parsed, err := ParseGenBank(scanner)
+		if err != nil || !reflect.DeepEqual(parsed, entry) {
+			t.Fatalf("Error: %s did not round trip (%v)\nWritten:\n%s", entry.Locus, err, text.String())
+		}
+	}
+}
+
+func TestWriteGenBankSequence(t *testing.T) {
+	entry := &GenBankEntry{
+		Locus:        "TEST",
+		MoleculeType: "ss-RNA",
+		Topology:     "circular",
+		Division:     "VRL",
+		Date:         "01-JAN-2000",
+		Features: []Feature{{Key: "CDS", Location: "complement(1..70)", Qualifiers: map[string]string{
+			"/pseudo": "", "/codon_start": "1", "/note": `a "quoted" note`, "/gene": "tst",
+		}}},
+		Sequence: strings.Repeat("acgt", 17) + "nn",
+	}
+	var text strings.Builder
+	if err := WriteGenBank(&text, entry); err != nil {
+		t.Fatalf("WriteGenBank failed: %v", err)
+	}
+	expected := `LOCUS       TEST                      70 bp ss-RNA     circular VRL 01-JAN-2000
+KEYWORDS    .
+FEATURES             Location/Qualifiers
+     CDS             complement(1..70)
+                     /gene="tst"
+                     /codon_start=1
+                     /note="a ""quoted"" note"
+                     /pseudo
+BASE COUNT       17 a     17 c     17 g     17 t      2 others
+ORIGIN
+        1 acgtacgtac gtacgtacgt acgtacgtac gtacgtacgt acgtacgtac gtacgtacgt
+       61 acgtacgtnn
+//
+`
+	if text.String() != expected {
+		t.Errorf("Error: WriteGenBank() =\n%s\nexpected:\n%s", text.String(), expected)
+	}
+
+	entry.Length = 70
+	scanner := &parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(text.String()))}
+	if parsed, err := ParseGenBank(scanner); err != nil || !reflect.DeepEqual(parsed, entry) {
+		t.Errorf("Error: ParseGenBank() = %+v, %v, expected: %+v", parsed, err, entry)
+	}
+}
+
+func TestWriteGenBankLongWords(t *testing.T) {
+	url := "https://www.example.org/" + strings.Repeat("x", 80)
+	note := "see " + url + " for details"
+	location := "join(" + strings.Repeat("1..10,", 12) + "complement(AC000001.1:100..2000000000000000000000000000000000000000000000000000000000))"
+	entry := &GenBankEntry{
+		Locus:    "TEST",
+		Features: []Feature{{Key: "misc_feature", Location: location, Qualifiers: map[string]string{"/note": note}}},
+		Sequence: "acgt",
+	}
+	var text strings.Builder
+	if err := WriteGenBank(&text, entry); err != nil {
+		t.Fatalf("WriteGenBank failed: %v", err)
+	}
+	// Only the line holding the long word may exceed the width
+	for _, line := range strings.Split(text.String(), "\n") {
+		if len(line) > genBankLineWidth && strings.TrimSpace(line) != url {
+			t.Errorf("Error: line exceeds %d columns: %q", genBankLineWidth, line)
+		}
+	}
+	if !strings.Contains(text.String(), "\n                     "+url+"\n") {
+		t.Errorf("Error: WriteGenBank() did not keep the long word whole:\n%s", text.String())
+	}
+
+	scanner := &parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(text.String()))}
+	parsed, err := ParseGenBank(scanner)
+	if err != nil {
+		t.Fatalf("Error: ParseGenBank() returned %v", err)
+	}
+	if parsed.Features[0].Location != location {
+		t.Errorf("Error: ParseGenBank() location = %s, expected: %s", parsed.Features[0].Location, location)
+	}
+	if value := parsed.Features[0].Qualifiers["/note"]; value != note {
+		t.Errorf("Error: ParseGenBank() /note = %q, expected: %q", value, note)
+	}
+}
+
+func TestWriteEMBL(t *testing.T) {
+	entry := &EMBLEntry{
+		ID:           "X56734",
+		Version:      "1",
+		Topology:     "linear",
+		MoleculeType: "mRNA",
+		DataClass:    "STD",
+		Division:     "PLN",
+		Length:       70,
+		Accession:    []string{"X56734", "S46826"},
+		Dates:        []string{"12-SEP-1991 (Rel. 29, Created)", "25-NOV-2005 (Rel. 85, Last updated, Version 11)"},
+		Description:  "Trifolium repens mRNA for non-cyanogenic beta-glucosidase",
+		Keywords:     []string{"beta-glucosidase"},
+		Source:       "Trifolium repens (white clover)",
+		Taxonomy:     []string{"Eukaryota", "Viridiplantae", "Streptophyta", "Embryophyta", "Tracheophyta"},
+		References: []EMBLReference{{
+			Number:          "1",
+			Positions:       "1-70",
+			CrossReferences: []string{"DOI; 10.1007/BF00039495", "PUBMED; 1907511"},
+			Authors:         "Oxtoby E., Dunn M.A., Pancoro A., Hughes M.A.",
+			Title:           "Nucleotide and derived amino acid sequence of the cyanogenic beta-glucosidase (linamarase) from white clover (Trifolium repens L.)",
+			Location:        "Plant Mol. Biol. 17(2):209-219(1991).",
+		}},
+		CrossReferences: []string{"MD5; 1e51ca3a5450c43524b9185c236cc5cc"},
+		Comment:         "first line\nsecond line",
+		Features: []Feature{{Key: "CDS", Location: "join(1..10,21..70)", Qualifiers: map[string]string{
+			"/product": "beta-glucosidase", "/translation": "MDFLA",
+		}}},
+		Sequence: strings.Repeat("aacc", 17) + "gt",
+	}
+	var text strings.Builder
+	if err := WriteEMBL(&text, entry); err != nil {
+		t.Fatalf("WriteEMBL failed: %v", err)
+	}
+	if lines := strings.Split(text.String(), "\n"); lines[0] != "ID   X56734; SV 1; linear; mRNA; STD; PLN; 70 BP." ||
+		!strings.Contains(text.String(), "\nSQ   Sequence 70 BP; 34 A; 34 C; 1 G; 1 T; 0 other;\n") ||
+		!strings.Contains(text.String(), "\n     aaccaaccaa ccaaccaacc aaccaaccaa ccaaccaacc aaccaaccaa ccaaccaacc        60\n") {
+		t.Errorf("Error: WriteEMBL() =\n%s", text.String())
+	}
+	for _, line := range strings.Split(text.String(), "\n") {
+		if len(line) > emblLineWidth {
+			t.Errorf("Error: line exceeds %d columns: %q", emblLineWidth, line)
+		}
+	}
+
+	scanner := &parseio.Scanalyzer{Scanner: bufio.NewScanner(strings.NewReader(text.String()))}
+	if parsed, err := ParseEMBL(scanner); err != nil || !reflect.DeepEqual(parsed, entry) {
+		t.Errorf("Error: ParseEMBL() = %+v, %v, expected: %+v\nWritten:\n%s", parsed, err, entry, text.String())
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"gopher-proteinlab/annotation"
+	"gopher-proteinlab/parseio"
+)
+
+// detectFormat peeks at the first non-blank line of a flat file and returns "genbank" for a LOCUS
+// line or "embl" for an ID line.
+func detectFormat(scanner *parseio.Scanalyzer) (string, error) {
+	for line, ok := scanner.Peek(); ok; line, ok = scanner.Peek() {
+		switch {
+		case strings.TrimSpace(line) == "":
+			scanner.Scan()
+		case strings.HasPrefix(line, "LOCUS"):
+			return "genbank", nil
+		case strings.HasPrefix(line, "ID   "):
+			return "embl", nil
+		default:
+			return "", fmt.Errorf("unrecognized flat file line %q", line)
+		}
+	}
+	return "", fmt.Errorf("no records found")
+}
+
+// convert reads every record of the input file and writes it to the output file in the other
+// format, returning the number of records converted and, in lenient mode, the errors of the
+// records that were skipped.
+func convert(inputFilename, outputFilename string, mode annotation.ParseMode) (int, []*annotation.ParseError, error) {
+	scanner := parseio.NewScanner(inputFilename)
+	format, err := detectFormat(scanner)
+	scanner.Close()
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %v", inputFilename, err)
+	}
+
+	writer := parseio.NewWriter(outputFilename)
+	defer writer.Close()
+
+	if format == "genbank" {
+		reader := annotation.NewGenBankReader(inputFilename)
+		defer reader.Close()
+		reader.SetMode(mode)
+		for count := 0; ; count++ {
+			entry, err := reader.Read()
+			if err == io.EOF {
+				return count, reader.Errors(), writer.Close()
+			}
+			if err != nil {
+				return count, reader.Errors(), err
+			}
+			if err = annotation.WriteEMBL(writer, annotation.GenBankToEMBL(entry)); err != nil {
+				return count, reader.Errors(), err
+			}
+		}
+	}
+
+	reader := annotation.NewEMBLReader(inputFilename)
+	defer reader.Close()
+	reader.SetMode(mode)
+	for count := 0; ; count++ {
+		entry, err := reader.Read()
+		if err == io.EOF {
+			return count, reader.Errors(), writer.Close()
+		}
+		if err != nil {
+			return count, reader.Errors(), err
+		}
+		if err = annotation.WriteGenBank(writer, annotation.EMBLToGenBank(entry)); err != nil {
+			return count, reader.Errors(), err
+		}
+	}
+}
+
+func usage() {
+	fmt.Println("Usage: go run seqconvert.go -in=<input> -out=<output>")
+	fmt.Println("\nConverts a GenBank flat file to EMBL or an EMBL flat file to GenBank. The input format")
+	fmt.Println("is detected from its first line and files ending in .gz are read and written gzipped.")
+	fmt.Println("\nOptions:")
+	fmt.Println("  -in\t\tThe GenBank or EMBL file to convert.")
+	fmt.Println("  -out\t\tThe file to write the converted records to.")
+	fmt.Println("  -lenient\tSkip malformed records and report them instead of stopping at the first one.")
+	fmt.Println("\nExample:")
+	fmt.Println("  go run seqconvert.go -in=human.gbff.gz -out=human.embl.gz")
+}
+
+func main() {
+	inputPtr := flag.String("in", "", "GenBank or EMBL file to convert")
+	outputPtr := flag.String("out", "", "Output file in the other format")
+	lenientPtr := flag.Bool("lenient", false, "Skip malformed records instead of stopping")
+
+	// Override the default usage message
+	flag.Usage = usage
+
+	flag.Parse()
+
+	if *inputPtr == "" || *outputPtr == "" {
+		flag.Usage()
+		log.Fatal("Error: Please provide the input and output files using the -in and -out flags")
+	}
+
+	mode := annotation.Strict
+	if *lenientPtr {
+		mode = annotation.Lenient
+	}
+	count, skipped, err := convert(*inputPtr, *outputPtr, mode)
+	for _, parseErr := range skipped {
+		log.Printf("Skipped: %v", parseErr)
+	}
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	log.Printf("Converted %d records from %s to %s", count, *inputPtr, *outputPtr)
+}
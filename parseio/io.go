@@ -22,10 +22,14 @@ type CodeWriter struct {
 	close func() error
 }
 
-// Scanalyzer structwraps around bufio.Scanner and adds a close method.
+// Scanalyzer structwraps around bufio.Scanner and adds a close method, line numbers and one line of lookahead.
 type Scanalyzer struct {
 	*bufio.Scanner
-	close func() error
+	close  func() error
+	text   string
+	next   string
+	peeked bool
+	line   int
 }
 
 // VimOpen opens a file and it handles errors gracefully.
@@ -82,14 +86,31 @@ func NewScanner(filename string) *Scanalyzer {
 	}
 }
 
+// NewWriter creates a new CodeWriter, gzipping the output when filename ends with .gz.
 func NewWriter(filename string) *CodeWriter {
-	ans := CodeWriter{}
 	file, err := os.Create(filename)
 	ExitOnError(err)
-	ans.Writer = bufio.NewWriter(file)
+	buffer := bufio.NewWriter(file)
+	ans := CodeWriter{
+		Writer: buffer,
+		close: func() error {
+			if err := buffer.Flush(); err != nil {
+				return err
+			}
+			return file.Close()
+		},
+	}
 
 	if strings.HasSuffix(filename, ".gz") {
-		ans.Writer = pgzip.NewWriter(ans.Writer)
+		gzip := pgzip.NewWriter(buffer)
+		flush := ans.close
+		ans.Writer = gzip
+		ans.close = func() error {
+			if err := gzip.Close(); err != nil {
+				return err
+			}
+			return flush()
+		}
 	}
 	return &ans
 }
@@ -107,6 +128,51 @@ func (r *CodeReader) Close() error {
 	return nil
 }
 
+// Scan advances to the next line, returning the line read ahead by Peek first if there is one.
+func (s *Scanalyzer) Scan() bool {
+	if s.peeked {
+		s.text, s.peeked = s.next, false
+	} else if s.Scanner.Scan() {
+		s.text = s.Scanner.Text()
+	} else {
+		return false
+	}
+	s.line++
+	return true
+}
+
+// Text returns the most recent line read by Scan.
+func (s *Scanalyzer) Text() string {
+	return s.text
+}
+
+// Peek returns the next line without consuming it, or false when the input is exhausted.
+func (s *Scanalyzer) Peek() (string, bool) {
+	if !s.peeked {
+		if !s.Scanner.Scan() {
+			return "", false
+		}
+		s.next, s.peeked = s.Scanner.Text(), true
+	}
+	return s.next, true
+}
+
+// Line returns the 1-based line number of the most recent line read by Scan.
+func (s *Scanalyzer) Line() int {
+	return s.line
+}
+
+// Close flushes any buffered or compressed data and closes the underlying file. Calling Close
+// again has no effect.
+func (w *CodeWriter) Close() error {
+	if w.close != nil {
+		close := w.close
+		w.close = nil
+		return close()
+	}
+	return nil
+}
+
 // Close is the method to close the underlying resource, such as a file.
 func (s *Scanalyzer) Close() error {
 	if s.close != nil {
@@ -90,3 +90,45 @@ func TestNewScannerio(t *testing.T) {
 	}
 
 }
+
+func TestScanalyzerPeek(t *testing.T) {
+	scanner := &Scanalyzer{Scanner: bufio.NewScanner(bytes.NewReader([]byte("line1\nline2\n")))}
+	if next, ok := scanner.Peek(); !ok || next != "line1" || scanner.Line() != 0 {
+		t.Errorf("Error: Peek() = %q, %v before any Scan()", next, ok)
+	}
+	if !scanner.Scan() || scanner.Text() != "line1" || scanner.Line() != 1 {
+		t.Errorf("Error: Scan() after Peek() = %q at line %d, expected: line1 at line 1", scanner.Text(), scanner.Line())
+	}
+	if next, ok := scanner.Peek(); !ok || next != "line2" || scanner.Text() != "line1" {
+		t.Errorf("Error: Peek() = %q changed the current line %q", next, scanner.Text())
+	}
+	if !scanner.Scan() || scanner.Text() != "line2" || scanner.Line() != 2 {
+		t.Errorf("Error: Scan() = %q at line %d, expected: line2 at line 2", scanner.Text(), scanner.Line())
+	}
+	if _, ok := scanner.Peek(); ok || scanner.Scan() {
+		t.Errorf("Error: expected the end of input after two lines")
+	}
+}
+
+func TestCodeWriter(t *testing.T) {
+	for _, filename := range []string{"testdata/writer.txt", "testdata/writer.txt.gz"} {
+		writer := NewWriter(filename)
+		if _, err := writer.Write([]byte("line1\nline2\n")); err != nil {
+			t.Errorf("Error: Write() returned %v", err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Errorf("Error: Close() returned %v", err)
+		}
+
+		var lines []string
+		scanner := NewScanner(filename)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		ExitOnError(scanner.Close())
+		ExitOnError(os.Remove(filename))
+		if len(lines) != 2 || lines[0] != "line1" || lines[1] != "line2" {
+			t.Errorf("Error: reading back %s gave %q", filename, lines)
+		}
+	}
+}
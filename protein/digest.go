@@ -0,0 +1,124 @@
+package protein
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Enzyme describes the cleavage rule of a protease or chemical. After and Before list the one
+// letter codes of the residues cleaved on their C-terminal and N-terminal side.
+type Enzyme struct {
+	Name    string
+	After   string
+	Before  string
+	Proline bool // No cleavage after a residue of After when Pro follows
+}
+
+// Enzymes holds the cleavage rules of ExPASy PeptideCutter keyed by lower case name. Chymotrypsin
+// is the high specificity rule and pepsin the rule at pH 1.3, cleaving on both sides of Phe and
+// Leu.
+var Enzymes = map[string]Enzyme{
+	"trypsin":      {"Trypsin", "KR", "", true},
+	"lys-c":        {"Lys-C", "K", "", false},
+	"lys-n":        {"Lys-N", "", "K", false},
+	"arg-c":        {"Arg-C", "R", "", true},
+	"asp-n":        {"Asp-N", "", "D", false},
+	"glu-c":        {"Glu-C", "E", "", false},
+	"chymotrypsin": {"Chymotrypsin", "FYW", "", true},
+	"pepsin":       {"Pepsin", "FL", "FL", false},
+	"cnbr":         {"CNBr", "M", "", false},
+}
+
+// LookupEnzyme returns the enzyme with the given name, ignoring case.
+func LookupEnzyme(name string) (Enzyme, error) {
+	if enzyme, ok := Enzymes[strings.ToLower(name)]; ok {
+		return enzyme, nil
+	}
+	names := make([]string, 0, len(Enzymes))
+	for _, enzyme := range Enzymes {
+		names = append(names, enzyme.Name)
+	}
+	sort.Strings(names)
+	return Enzyme{}, fmt.Errorf("unknown enzyme %q, expected one of %v", name, names)
+}
+
+// Cleaves reports whether the enzyme cleaves the bond between residues n and c.
+func (e Enzyme) Cleaves(n, c Protein) bool {
+	if strings.IndexByte(e.After, byte(n)) >= 0 && !(e.Proline && c == Pro) {
+		return true
+	}
+	return strings.IndexByte(e.Before, byte(c)) >= 0
+}
+
+// DigestOptions restricts the peptides returned by Digest.
+type DigestOptions struct {
+	MissedCleavages int  // Maximum number of uncleaved sites within a peptide
+	MinLength       int  // Minimum number of residues
+	MaxLength       int  // Maximum number of residues, or 0 for no limit
+	SemiSpecific    bool // Also return peptides with one terminus that is not a cleavage site
+}
+
+// Peptide is a product of a digest. Start and End are the 1-based positions of its first and last
+// residue in the protein, and Sequence shares the memory of the protein.
+type Peptide struct {
+	Start           int
+	End             int
+	MissedCleavages int
+	Sequence        []Protein
+}
+
+// Digest cleaves a protein with an enzyme and returns the peptides, ordered by Start and End.
+func Digest(seq []Protein, enzyme Enzyme, opts DigestOptions) []Peptide {
+	// sites lists the cleavage sites as offsets between residues, including both ends, and
+	// cleaved[i] counts the sites among offsets 1..i
+	sites := []int{0}
+	cleaved := make([]int, len(seq)+1)
+	for i := 1; i < len(seq); i++ {
+		cleaved[i] = cleaved[i-1]
+		if enzyme.Cleaves(seq[i-1], seq[i]) {
+			sites = append(sites, i)
+			cleaved[i]++
+		}
+	}
+	if len(seq) > 0 {
+		sites = append(sites, len(seq))
+		cleaved[len(seq)] = cleaved[len(seq)-1]
+	}
+
+	spans := make(map[[2]int]bool)
+	for j := 0; j < len(sites); j++ {
+		for k := j + 1; k < len(sites) && k-j-1 <= opts.MissedCleavages; k++ {
+			start, end := sites[j], sites[k]
+			spans[[2]int{start, end}] = true
+			if !opts.SemiSpecific {
+				continue
+			}
+			for i := start + 1; i < end; i++ {
+				spans[[2]int{start, i}] = true
+				spans[[2]int{i, end}] = true
+			}
+		}
+	}
+
+	peptides := make([]Peptide, 0, len(spans))
+	for span := range spans {
+		start, end := span[0], span[1]
+		if end-start < opts.MinLength || (opts.MaxLength > 0 && end-start > opts.MaxLength) {
+			continue
+		}
+		peptides = append(peptides, Peptide{
+			Start:           start + 1,
+			End:             end,
+			MissedCleavages: cleaved[end-1] - cleaved[start],
+			Sequence:        seq[start:end],
+		})
+	}
+	sort.Slice(peptides, func(i, j int) bool {
+		if peptides[i].Start != peptides[j].Start {
+			return peptides[i].Start < peptides[j].Start
+		}
+		return peptides[i].End < peptides[j].End
+	})
+	return peptides
+}
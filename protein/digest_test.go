@@ -0,0 +1,53 @@
+package protein
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// peptideStrings formats peptides as sequence:start-end/missed for comparison.
+func peptideStrings(peptides []Peptide) []string {
+	var result []string
+	for _, p := range peptides {
+		result = append(result, fmt.Sprintf("%s:%d-%d/%d", ToString(p.Sequence), p.Start, p.End, p.MissedCleavages))
+	}
+	return result
+}
+
+func TestDigest(t *testing.T) {
+	trypsin, err := LookupEnzyme("Trypsin")
+	if err != nil {
+		t.Fatalf("LookupEnzyme failed: %v", err)
+	}
+	lysN, _ := LookupEnzyme("lys-n")
+	pepsin, _ := LookupEnzyme("pepsin")
+	tests := []struct {
+		seq      string
+		enzyme   Enzyme
+		opts     DigestOptions
+		expected []string
+	}{
+		// No cleavage between K and P
+		{"MKPRAKR", trypsin, DigestOptions{}, []string{"MKPR:1-4/0", "AK:5-6/0", "R:7-7/0"}},
+		{"MKPRAKR", trypsin, DigestOptions{MissedCleavages: 1}, []string{"MKPR:1-4/0", "MKPRAK:1-6/1", "AK:5-6/0", "AKR:5-7/1", "R:7-7/0"}},
+		{"MKPRAKR", trypsin, DigestOptions{MissedCleavages: 2, MinLength: 3, MaxLength: 6}, []string{"MKPR:1-4/0", "MKPRAK:1-6/1", "AKR:5-7/1"}},
+		{"AKDKK", lysN, DigestOptions{}, []string{"A:1-1/0", "KD:2-3/0", "K:4-4/0", "K:5-5/0"}},
+		// Pepsin cleaves on both sides of Phe and Leu
+		{"AFGLAK", pepsin, DigestOptions{}, []string{"A:1-1/0", "F:2-2/0", "G:3-3/0", "L:4-4/0", "AK:5-6/0"}},
+		{"AKR", trypsin, DigestOptions{SemiSpecific: true}, []string{"A:1-1/0", "AK:1-2/0", "K:2-2/0", "R:3-3/0"}},
+		{"", trypsin, DigestOptions{}, nil},
+	}
+	for _, test := range tests {
+		if peptides := peptideStrings(Digest(ToProteins(test.seq), test.enzyme, test.opts)); !reflect.DeepEqual(peptides, test.expected) {
+			t.Errorf("Error: Digest(%s, %s, %+v) = %v, expected: %v", test.seq, test.enzyme.Name, test.opts, peptides, test.expected)
+		}
+	}
+
+	if _, err := LookupEnzyme("papain"); err == nil {
+		t.Errorf("Error: LookupEnzyme(papain) expected an error")
+	}
+	if cnbr := Enzymes["cnbr"]; !cnbr.Cleaves(Met, Pro) || cnbr.Cleaves(Pro, Met) {
+		t.Errorf("Error: CNBr expected to cleave after Met only")
+	}
+}
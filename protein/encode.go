@@ -0,0 +1,102 @@
+package protein
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Gap is an alignment gap, read from '-' or '.' and written as '-'.
+const Gap Protein = '-'
+
+// EncodeOptions selects which characters Encode accepts besides the upper case one letter codes.
+type EncodeOptions struct {
+	FoldCase       bool // Accept lower case codes
+	Gaps           bool // Accept '-' and '.' as Gap
+	ReplaceUnknown bool // Encode invalid characters as Xaa instead of reporting them
+}
+
+// InvalidResidueError lists every invalid character of a sequence passed to Encode.
+type InvalidResidueError struct {
+	Positions []int  // 1-based positions of the invalid characters
+	Symbols   []byte // The invalid characters, in the order of Positions
+}
+
+// maxReportedResidues limits the number of invalid characters spelled out by Error.
+const maxReportedResidues = 10
+
+// Error lists the invalid characters with their positions.
+func (e *InvalidResidueError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d invalid amino acid symbols:", len(e.Positions))
+	for i := range e.Positions {
+		if i == maxReportedResidues {
+			fmt.Fprintf(&sb, " and %d more", len(e.Positions)-i)
+			break
+		}
+		fmt.Fprintf(&sb, " %q at %d", e.Symbols[i], e.Positions[i])
+	}
+	return sb.String()
+}
+
+// encodeTable maps every byte to its Protein, or Unknown, and decodeTable maps every Protein
+// with a one letter code back to its byte.
+var (
+	encodeTable [256]Protein
+	decodeTable [256]byte
+)
+
+func init() {
+	for i := range encodeTable {
+		encodeTable[i] = Unknown
+	}
+	for b, aa := range AminoAcidMap {
+		encodeTable[b] = aa
+		decodeTable[aa] = b
+	}
+	decodeTable[Gap] = '-'
+}
+
+// Encode converts text to Protein amino acids using a lookup table. Unless opts tell otherwise
+// only the upper case one letter codes and * are accepted, and every other character is reported
+// in an *InvalidResidueError.
+func Encode(text string, opts EncodeOptions) ([]Protein, error) {
+	proteins := make([]Protein, len(text))
+	var invalid *InvalidResidueError
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		if opts.FoldCase && b >= 'a' && b <= 'z' {
+			b -= 'a' - 'A'
+		}
+		aa := encodeTable[b]
+		if opts.Gaps && (b == '-' || b == '.') {
+			aa = Gap
+		}
+		if aa == Unknown {
+			if !opts.ReplaceUnknown {
+				if invalid == nil {
+					invalid = &InvalidResidueError{}
+				}
+				invalid.Positions = append(invalid.Positions, i+1)
+				invalid.Symbols = append(invalid.Symbols, text[i])
+			}
+			aa = Xaa
+		}
+		proteins[i] = aa
+	}
+	if invalid != nil {
+		return nil, invalid
+	}
+	return proteins, nil
+}
+
+// Decode converts Protein amino acids to their one letter codes using a lookup table. Residues
+// without a one letter code, such as Unknown and the unusual amino acids, are written as X.
+func Decode(proteins []Protein) string {
+	text := make([]byte, len(proteins))
+	for i, aa := range proteins {
+		if text[i] = decodeTable[aa]; text[i] == 0 {
+			text[i] = 'X'
+		}
+	}
+	return string(text)
+}
@@ -0,0 +1,60 @@
+package protein
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text     string
+		opts     EncodeOptions
+		expected string
+		invalid  []int
+	}{
+		{"MKV*", EncodeOptions{}, "MKV*", nil},
+		{"mkV", EncodeOptions{}, "", []int{1, 2}},
+		{"mkV", EncodeOptions{FoldCase: true}, "MKV", nil},
+		{"MK-.V", EncodeOptions{}, "", []int{3, 4}},
+		{"MK-.V", EncodeOptions{Gaps: true}, "MK--V", nil},
+		{"M1K V", EncodeOptions{ReplaceUnknown: true}, "MXKXV", nil},
+		{"m-1", EncodeOptions{FoldCase: true, Gaps: true, ReplaceUnknown: true}, "M-X", nil},
+	}
+	for _, test := range tests {
+		proteins, err := Encode(test.text, test.opts)
+		if test.invalid != nil {
+			invalid, ok := err.(*InvalidResidueError)
+			if !ok || len(invalid.Positions) != len(test.invalid) {
+				t.Errorf("Error: Encode(%q, %+v) error = %v, expected positions: %v", test.text, test.opts, err, test.invalid)
+				continue
+			}
+			for i, position := range test.invalid {
+				if invalid.Positions[i] != position || invalid.Symbols[i] != test.text[position-1] {
+					t.Errorf("Error: Encode(%q) reported %q at %d, expected position %d", test.text, invalid.Symbols[i], invalid.Positions[i], position)
+				}
+			}
+			continue
+		}
+		if err != nil || Decode(proteins) != test.expected {
+			t.Errorf("Error: Encode(%q, %+v) = %s, %v, expected: %s", test.text, test.opts, Decode(proteins), err, test.expected)
+		}
+	}
+
+	_, err := Encode("MK1V2", EncodeOptions{})
+	if expected := `2 invalid amino acid symbols: '1' at 3 '2' at 5`; err == nil || err.Error() != expected {
+		t.Errorf("Error: Encode() error = %v, expected: %s", err, expected)
+	}
+	_, err = Encode(strings.Repeat("1", 12), EncodeOptions{})
+	if err == nil || !strings.HasSuffix(err.Error(), "'1' at 10 and 2 more") {
+		t.Errorf("Error: Encode() error = %v, expected the list to be truncated", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	if text := Decode([]Protein{Met, Gap, Unknown, Orn, Stop}); text != "M-XX*" {
+		t.Errorf("Error: Decode() = %s, expected: M-XX*", text)
+	}
+	if text := ToString(ToProteins("MK?V")); text != "MKXV" {
+		t.Errorf("Error: ToString(ToProteins()) = %s, expected: MKXV", text)
+	}
+}
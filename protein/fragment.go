@@ -0,0 +1,199 @@
+package protein
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Monoisotopic masses in daltons of the proton and of the groups that distinguish the ion series.
+const (
+	ProtonMass   = 1.00727646688
+	HydrogenMass = 1.00782503207
+	AmmoniaMass  = 17.02654910
+	COMass       = 27.99491462
+)
+
+// IonType is a fragment ion series.
+type IonType int
+
+const (
+	AIon IonType = iota // b minus CO
+	BIon                // N-terminal residues plus a proton
+	CIon                // b plus NH3
+	XIon                // y plus CO minus 2 H
+	YIon                // C-terminal residues plus water and a proton
+	ZIon                // The z-dot radical ion, y minus NH3 plus H
+)
+
+// String returns the letter of the ion series.
+func (t IonType) String() string {
+	return [...]string{"a", "b", "c", "x", "y", "z"}[t]
+}
+
+// nTerminal reports whether the ion series holds N-terminal fragments.
+func (t IonType) nTerminal() bool {
+	return t <= CIon
+}
+
+// offset returns the mass added to the residue masses of a singly charged fragment.
+func (t IonType) offset() float64 {
+	switch t {
+	case AIon:
+		return ProtonMass - COMass
+	case BIon:
+		return ProtonMass
+	case CIon:
+		return ProtonMass + AmmoniaMass
+	case XIon:
+		return WaterMonoisotopicMass + ProtonMass + COMass - 2*HydrogenMass
+	case YIon:
+		return WaterMonoisotopicMass + ProtonMass
+	default:
+		return WaterMonoisotopicMass + ProtonMass - AmmoniaMass + HydrogenMass
+	}
+}
+
+// residueMasses returns the monoisotopic mass of every residue of the peptide including its
+// modifications.
+func residueMasses(peptide []Protein, mods []SiteModification) []float64 {
+	masses := make([]float64, len(peptide))
+	for i, aa := range peptide {
+		p, _ := aa.Properties()
+		masses[i] = p.MonoisotopicMass
+	}
+	for _, site := range mods {
+		masses[site.Position] += site.Modification.Mass
+	}
+	return masses
+}
+
+// PeptideMass returns the neutral monoisotopic mass of a peptide with modifications.
+func PeptideMass(peptide []Protein, mods []SiteModification) float64 {
+	mass := WaterMonoisotopicMass
+	for _, m := range residueMasses(peptide, mods) {
+		mass += m
+	}
+	return mass
+}
+
+// MassToCharge returns the m/z of an ion of the given neutral mass carrying charge protons.
+func MassToCharge(mass float64, charge int) float64 {
+	return (mass + float64(charge)*ProtonMass) / float64(charge)
+}
+
+// PrecursorMZ returns the m/z of a peptide with modifications at the given charge state.
+func PrecursorMZ(peptide []Protein, mods []SiteModification, charge int) float64 {
+	return MassToCharge(PeptideMass(peptide, mods), charge)
+}
+
+// Fragment is a fragment ion. Number counts the residues of the fragment, so b2 holds the first
+// two residues and y2 the last two.
+type Fragment struct {
+	Type   IonType
+	Number int
+	Charge int
+	Loss   string // Formula of the neutral loss, empty for the intact fragment
+	MZ     float64
+}
+
+// Label returns the conventional name of the fragment, e.g. b2, y3++ or y3-H2O.
+func (f Fragment) Label() string {
+	label := f.Type.String() + strconv.Itoa(f.Number)
+	if f.Charge > 1 {
+		label += strings.Repeat("+", f.Charge)
+	}
+	if f.Loss != "" {
+		label += "-" + f.Loss
+	}
+	return label
+}
+
+// FragmentOptions selects the fragments returned by Fragments. Neutral losses are water from
+// fragments with S, T, E or D, ammonia from fragments with R, K, N or Q, and the losses of their
+// modifications.
+type FragmentOptions struct {
+	Ions          []IonType // Ion series, b and y when empty
+	MaxCharge     int       // Highest charge state, 1 when 0
+	NeutralLosses bool
+}
+
+// neutralLoss is a mass lost by fragments that contain one of residues.
+type neutralLoss struct {
+	label    string
+	mass     float64
+	residues string
+}
+
+var neutralLosses = []neutralLoss{
+	{"H2O", WaterMonoisotopicMass, "STED"},
+	{"NH3", AmmoniaMass, "RKNQ"},
+}
+
+// Fragments returns the fragment ion ladders of a peptide with modifications, for fragments of
+// 1 to len(peptide)-1 residues, ordered by series, charge, neutral loss and number.
+func Fragments(peptide []Protein, mods []SiteModification, opts FragmentOptions) []Fragment {
+	if len(peptide) < 2 {
+		return nil
+	}
+	ions := opts.Ions
+	if len(ions) == 0 {
+		ions = []IonType{BIon, YIon}
+	}
+	maxCharge := max(opts.MaxCharge, 1)
+	masses := residueMasses(peptide, mods)
+
+	// losses[i] lists the neutral losses available to residue i
+	losses := make([][]neutralLoss, len(peptide))
+	if opts.NeutralLosses {
+		for i, aa := range peptide {
+			for _, loss := range neutralLosses {
+				if strings.IndexByte(loss.residues, byte(aa)) >= 0 {
+					losses[i] = append(losses[i], loss)
+				}
+			}
+		}
+		for _, site := range mods {
+			if mod := site.Modification; mod.NeutralLoss != 0 {
+				losses[site.Position] = append(losses[site.Position], neutralLoss{mod.NeutralLabel, mod.NeutralLoss, ""})
+			}
+		}
+	}
+
+	var fragments []Fragment
+	for _, ion := range ions {
+		// ladder[n-1] is the residue mass of the fragment of n residues and first[label] the
+		// smallest fragment that can lose the neutral loss
+		ladder := make([]float64, len(peptide)-1)
+		var order []neutralLoss
+		first := make(map[string]int)
+		var sum float64
+		for n := 1; n < len(peptide); n++ {
+			i := n - 1
+			if !ion.nTerminal() {
+				i = len(peptide) - n
+			}
+			sum += masses[i]
+			ladder[n-1] = sum
+			for _, loss := range losses[i] {
+				if _, ok := first[loss.label]; !ok {
+					first[loss.label] = n
+					order = append(order, loss)
+				}
+			}
+		}
+
+		for charge := 1; charge <= maxCharge; charge++ {
+			for n := 1; n < len(peptide); n++ {
+				mass := ladder[n-1] + ion.offset() - ProtonMass
+				fragments = append(fragments, Fragment{Type: ion, Number: n, Charge: charge, MZ: MassToCharge(mass, charge)})
+			}
+			for _, loss := range order {
+				for n := first[loss.label]; n < len(peptide); n++ {
+					mass := ladder[n-1] + ion.offset() - ProtonMass - loss.mass
+					fragments = append(fragments, Fragment{Type: ion, Number: n, Charge: charge, Loss: loss.label, MZ: MassToCharge(mass, charge)})
+				}
+			}
+		}
+	}
+	return fragments
+}
@@ -0,0 +1,58 @@
+package protein
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPeptideMass(t *testing.T) {
+	peptide := ToProteins("PEPTIDE")
+	if mass := PeptideMass(peptide, nil); math.Abs(mass-799.35997) > 1e-4 {
+		t.Errorf("Error: PeptideMass(PEPTIDE) = %f, expected: 799.35997", mass)
+	}
+	if mz := PrecursorMZ(peptide, nil, 2); math.Abs(mz-400.68726) > 1e-4 {
+		t.Errorf("Error: PrecursorMZ(PEPTIDE, 2) = %f, expected: 400.68726", mz)
+	}
+	mods := []SiteModification{{Position: 3, Modification: Phospho}}
+	if mass := PeptideMass(peptide, mods); math.Abs(mass-(799.35997+79.96633)) > 1e-4 {
+		t.Errorf("Error: PeptideMass(PEPpTIDE) = %f, expected: 879.32630", mass)
+	}
+}
+
+func TestFragments(t *testing.T) {
+	peptide := ToProteins("PEPTIDE")
+	fragments := Fragments(peptide, nil, FragmentOptions{Ions: []IonType{AIon, BIon, CIon, YIon, ZIon}, MaxCharge: 2})
+	if len(fragments) != 5*2*6 {
+		t.Fatalf("Error: Fragments() returned %d fragments, expected: 60", len(fragments))
+	}
+	expected := map[string]float64{
+		"a2": 199.10772, "b2": 227.10263, "c2": 244.12918, "y1": 148.06043, "z1": 132.04171,
+		"b2++": 114.05495, "y6++": 352.16087,
+	}
+	for _, f := range fragments {
+		if mz, ok := expected[f.Label()]; ok {
+			if math.Abs(f.MZ-mz) > 1e-4 {
+				t.Errorf("Error: %s m/z = %f, expected: %f", f.Label(), f.MZ, mz)
+			}
+			delete(expected, f.Label())
+		}
+	}
+	if len(expected) != 0 {
+		t.Errorf("Error: Fragments() is missing %v", expected)
+	}
+
+	// The phosphate is lost from b4 onwards, which contain the phosphothreonine
+	mods := []SiteModification{{Position: 3, Modification: Phospho}}
+	var losses []string
+	for _, f := range Fragments(peptide, mods, FragmentOptions{Ions: []IonType{BIon}, NeutralLosses: true}) {
+		if f.Loss == "H3PO4" {
+			losses = append(losses, f.Label())
+		}
+	}
+	if len(losses) != 3 || losses[0] != "b4-H3PO4" {
+		t.Errorf("Error: Fragments() phosphate losses = %v, expected: b4-H3PO4 to b6-H3PO4", losses)
+	}
+	if Fragments(ToProteins("P"), nil, FragmentOptions{}) != nil {
+		t.Errorf("Error: Fragments() of a single residue expected no fragments")
+	}
+}
@@ -0,0 +1,133 @@
+package protein
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GeneticCode is an NCBI translation table. AminoAcids and Starts list the 64 codons in the
+// NCBI TCAG order (TTT, TTC, TTA, TTG, TCT, ... GGG), with 'M' in Starts marking initiation codons.
+type GeneticCode struct {
+	ID         int
+	Name       string
+	AminoAcids string
+	Starts     string
+}
+
+// GeneticCodes holds every NCBI translation table keyed by its /transl_table number.
+var GeneticCodes = map[int]*GeneticCode{
+	1:  {1, "Standard", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M---------------M---------------M----------------------------"},
+	2:  {2, "Vertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG", "--------------------------------MMMM---------------M------------"},
+	3:  {3, "Yeast Mitochondrial", "FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------------------------------MM----------------------------"},
+	4:  {4, "Mold, Protozoan, and Coelenterate Mitochondrial and Mycoplasma/Spiroplasma", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--MM---------------M------------MMMM---------------M------------"},
+	5:  {5, "Invertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG", "---M----------------------------MMMM---------------M------------"},
+	6:  {6, "Ciliate, Dasycladacean and Hexamita Nuclear", "FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	9:  {9, "Echinoderm and Flatworm Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "-----------------------------------M---------------M------------"},
+	10: {10, "Euplotid Nuclear", "FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	11: {11, "Bacterial, Archaeal and Plant Plastid", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M---------------M------------MMMM---------------M------------"},
+	12: {12, "Alternative Yeast Nuclear", "FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-------------------M---------------M----------------------------"},
+	13: {13, "Ascidian Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG", "---M------------------------------MM---------------M------------"},
+	14: {14, "Alternative Flatworm Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	15: {15, "Blepharisma Macronuclear", "FFLLSSSSYY*QCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	16: {16, "Chlorophycean Mitochondrial", "FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	21: {21, "Trematode Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "-----------------------------------M---------------M------------"},
+	22: {22, "Scenedesmus obliquus Mitochondrial", "FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	23: {23, "Thraustochytrium Mitochondrial", "FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------------------------M--M---------------M------------"},
+	24: {24, "Rhabdopleuridae Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M---------------M---------------M---------------M------------"},
+	25: {25, "Candidate Division SR1 and Gracilibacteria", "FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M-------------------------------M---------------M------------"},
+	26: {26, "Pachysolen tannophilus Nuclear", "FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-------------------M---------------M----------------------------"},
+	27: {27, "Karyorelict Nuclear", "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	28: {28, "Condylostoma Nuclear", "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	29: {29, "Mesodinium Nuclear", "FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	30: {30, "Peritrich Nuclear", "FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	31: {31, "Blastocrithidia Nuclear", "FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "-----------------------------------M----------------------------"},
+	32: {32, "Balanophoraceae Plastid", "FFLLSSSSYY*WCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M---------------M------------MMMM---------------M------------"},
+	33: {33, "Cephalodiscidae Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M---------------M---------------M---------------M------------"},
+}
+
+// nucleotideBits maps a nucleotide to the set of TCAG bases it stands for, one bit per base.
+var nucleotideBits = func() [256]byte {
+	var table [256]byte
+	codes := map[byte]byte{
+		'T': 1, 'U': 1, 'C': 2, 'A': 4, 'G': 8,
+		'Y': 3, 'W': 5, 'K': 9, 'M': 6, 'S': 10, 'R': 12,
+		'H': 7, 'B': 11, 'D': 13, 'V': 14, 'N': 15,
+	}
+	for b, bits := range codes {
+		table[b] = bits
+		table[b+'a'-'A'] = bits
+	}
+	return table
+}()
+
+// LookupGeneticCode returns the NCBI translation table with the given number.
+func LookupGeneticCode(id int) (*GeneticCode, error) {
+	if code, ok := GeneticCodes[id]; ok {
+		return code, nil
+	}
+	ids := make([]int, 0, len(GeneticCodes))
+	for key := range GeneticCodes {
+		ids = append(ids, key)
+	}
+	sort.Ints(ids)
+	return nil, fmt.Errorf("Error: %d is not an NCBI translation table, expected one of %v.", id, ids)
+}
+
+// Codon translates a single codon. Codons containing IUPAC ambiguity codes translate to the
+// amino acid shared by every codon they expand to, to Asx, Glx or Xle when the expansion covers
+// exactly that pair, and to Xaa otherwise.
+func (g *GeneticCode) Codon(codon string) Protein {
+	seen := g.expand(codon, g.AminoAcids)
+	switch {
+	case len(seen) == 1:
+		return Protein(seen[0])
+	case seen == "DN":
+		return Asx
+	case seen == "EQ":
+		return Glx
+	case seen == "IL":
+		return Xle
+	}
+	return Xaa
+}
+
+// IsStart reports whether every codon that codon expands to is an initiation codon.
+func (g *GeneticCode) IsStart(codon string) bool {
+	return g.expand(codon, g.Starts) == "M"
+}
+
+// Translate translates a nucleotide sequence codon by codon, ignoring trailing bases that do
+// not form a complete codon. Stop codons are kept as Stop.
+func (g *GeneticCode) Translate(seq string) []Protein {
+	ans := make([]Protein, 0, len(seq)/3)
+	for i := 0; i+3 <= len(seq); i += 3 {
+		ans = append(ans, g.Codon(seq[i:i+3]))
+	}
+	return ans
+}
+
+// expand collects the sorted, distinct symbols of table for every codon that codon can stand for.
+// It returns an empty string when codon is not three valid nucleotides.
+func (g *GeneticCode) expand(codon string, table string) string {
+	if len(codon) != 3 {
+		return ""
+	}
+	first, second, third := nucleotideBits[codon[0]], nucleotideBits[codon[1]], nucleotideBits[codon[2]]
+	var seen [256]bool
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				if first&(1<<i) != 0 && second&(1<<j) != 0 && third&(1<<k) != 0 {
+					seen[table[16*i+4*j+k]] = true
+				}
+			}
+		}
+	}
+	var symbols []byte
+	for b, ok := range seen {
+		if ok {
+			symbols = append(symbols, byte(b))
+		}
+	}
+	return string(symbols)
+}
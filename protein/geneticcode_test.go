@@ -0,0 +1,56 @@
+package protein
+
+import (
+	"testing"
+)
+
+func TestGeneticCodes(t *testing.T) {
+	for id, code := range GeneticCodes {
+		if code.ID != id || len(code.AminoAcids) != 64 || len(code.Starts) != 64 {
+			t.Errorf("Error: translation table %d is malformed", id)
+		}
+	}
+	if _, err := LookupGeneticCode(7); err == nil {
+		t.Errorf("Error: LookupGeneticCode(7) expected an error")
+	}
+}
+
+func TestCodon(t *testing.T) {
+	standard, _ := LookupGeneticCode(1)
+	mito, _ := LookupGeneticCode(2)
+	tests := []struct {
+		code     *GeneticCode
+		codon    string
+		expected Protein
+	}{
+		{standard, "ATG", Met},
+		{standard, "tgg", Trp},
+		{standard, "UAA", Stop},
+		{standard, "TGA", Stop},
+		{mito, "TGA", Trp},
+		{mito, "AGA", Stop},
+		{mito, "ATA", Met},
+		{standard, "CTN", Leu},
+		{standard, "GAY", Asp},
+		{standard, "RAT", Asx},
+		{standard, "SAR", Glx},
+		{standard, "MTT", Xle},
+		{standard, "NNN", Xaa},
+		{standard, "AT", Xaa},
+	}
+	for _, test := range tests {
+		if aa := test.code.Codon(test.codon); aa != test.expected {
+			t.Errorf("Error: table %d Codon(%s) = %c, expected: %c", test.code.ID, test.codon, aa, test.expected)
+		}
+	}
+	if !standard.IsStart("TTG") || standard.IsStart("TTA") || !mito.IsStart("ATH") {
+		t.Errorf("Error: IsStart() does not match the initiation codons of the tables")
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	standard, _ := LookupGeneticCode(1)
+	if ans := ToString(standard.Translate("ATGGCCTGGTAAgc")); ans != "MAW*" {
+		t.Errorf("Error: Translate() = %s, expected: MAW*", ans)
+	}
+}
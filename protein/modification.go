@@ -0,0 +1,121 @@
+package protein
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Modification is a post-translational or chemical modification given by its monoisotopic mass
+// shift. Residues lists the one letter codes of the residues it modifies; an N-terminal
+// modification applies to the first residue of a peptide whatever it is.
+type Modification struct {
+	Name         string
+	Mass         float64
+	Residues     string
+	NTerm        bool
+	NeutralLoss  float64 // Mass lost by fragments carrying the modification, e.g. H3PO4, or 0
+	NeutralLabel string  // Formula of the neutral loss
+}
+
+// Common modifications with their Unimod monoisotopic mass shifts.
+var (
+	Carbamidomethyl = Modification{Name: "Carbamidomethyl", Mass: 57.021464, Residues: "C"}
+	Oxidation       = Modification{Name: "Oxidation", Mass: 15.994915, Residues: "M"}
+	Phospho         = Modification{Name: "Phospho", Mass: 79.966331, Residues: "STY", NeutralLoss: 97.976896, NeutralLabel: "H3PO4"}
+	Acetyl          = Modification{Name: "Acetyl", Mass: 42.010565, NTerm: true}
+)
+
+// Modifications holds the common modifications keyed by lower case name.
+var Modifications = map[string]Modification{
+	"carbamidomethyl": Carbamidomethyl,
+	"oxidation":       Oxidation,
+	"phospho":         Phospho,
+	"acetyl":          Acetyl,
+}
+
+// LookupModification returns the common modification with the given name, ignoring case.
+func LookupModification(name string) (Modification, error) {
+	if mod, ok := Modifications[strings.ToLower(name)]; ok {
+		return mod, nil
+	}
+	names := make([]string, 0, len(Modifications))
+	for _, mod := range Modifications {
+		names = append(names, mod.Name)
+	}
+	sort.Strings(names)
+	return Modification{}, fmt.Errorf("unknown modification %q, expected one of %v", name, names)
+}
+
+// Targets reports whether the modification can be placed on residue i of the peptide.
+func (m Modification) Targets(peptide []Protein, i int) bool {
+	if m.NTerm {
+		return i == 0
+	}
+	return strings.IndexByte(m.Residues, byte(peptide[i])) >= 0
+}
+
+// SiteModification places a modification on the residue at the 0-based Position of a peptide.
+type SiteModification struct {
+	Position     int
+	Modification Modification
+}
+
+// ModificationSites returns the modification sites of every isoform of a peptide. Fixed
+// modifications are placed on every residue they target. Variable modifications are combined on
+// up to maxVariable residues, at most one per residue and never on a residue whose side chain
+// already carries a fixed modification; N-terminal modifications count separately from the side
+// chain of the first residue. The unmodified isoform, carrying only the fixed modifications,
+// comes first.
+func ModificationSites(peptide []Protein, fixed, variable []Modification, maxVariable int) [][]SiteModification {
+	var base []SiteModification
+	taken := make(map[[2]int]bool) // Position and whether the N-terminus is modified
+	for i := range peptide {
+		for _, mod := range fixed {
+			if site := modificationSite(i, mod); mod.Targets(peptide, i) && !taken[site] {
+				base = append(base, SiteModification{Position: i, Modification: mod})
+				taken[site] = true
+			}
+		}
+	}
+
+	// candidates holds the variable modification sites, grouped by the site they occupy
+	var candidates []SiteModification
+	for i := range peptide {
+		for _, mod := range variable {
+			if mod.Targets(peptide, i) && !taken[modificationSite(i, mod)] {
+				candidates = append(candidates, SiteModification{Position: i, Modification: mod})
+			}
+		}
+	}
+
+	isoforms := [][]SiteModification{base}
+	var extend func(current []SiteModification, next int, used map[[2]int]bool)
+	extend = func(current []SiteModification, next int, used map[[2]int]bool) {
+		if len(current)-len(base) >= maxVariable {
+			return
+		}
+		for j := next; j < len(candidates); j++ {
+			site := modificationSite(candidates[j].Position, candidates[j].Modification)
+			if used[site] {
+				continue
+			}
+			used[site] = true
+			isoform := append(append([]SiteModification(nil), current...), candidates[j])
+			isoforms = append(isoforms, isoform)
+			extend(isoform, j+1, used)
+			delete(used, site)
+		}
+	}
+	extend(base, 0, make(map[[2]int]bool))
+	return isoforms
+}
+
+// modificationSite identifies the site a modification occupies, the N-terminus or the side chain
+// of a residue.
+func modificationSite(position int, mod Modification) [2]int {
+	if mod.NTerm {
+		return [2]int{position, 1}
+	}
+	return [2]int{position, 0}
+}
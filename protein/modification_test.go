@@ -0,0 +1,33 @@
+package protein
+
+import (
+	"testing"
+)
+
+func TestModificationSites(t *testing.T) {
+	peptide := ToProteins("MCSM")
+	isoforms := ModificationSites(peptide, []Modification{Carbamidomethyl}, []Modification{Oxidation, Phospho, Acetyl}, 2)
+
+	// Four variable sites: the N-terminus, Met1, Ser3 and Met4, combined one or two at a time
+	if len(isoforms) != 1+4+6 {
+		t.Fatalf("Error: ModificationSites() returned %d isoforms, expected: 11", len(isoforms))
+	}
+	if base := isoforms[0]; len(base) != 1 || base[0].Position != 1 || base[0].Modification.Name != "Carbamidomethyl" {
+		t.Errorf("Error: ModificationSites() first isoform = %+v, expected: Carbamidomethyl on Cys2", base)
+	}
+	for _, isoform := range isoforms {
+		if len(isoform) > 3 || isoform[0].Modification.Name != "Carbamidomethyl" {
+			t.Errorf("Error: ModificationSites() isoform = %+v", isoform)
+		}
+	}
+	if isoforms := ModificationSites(peptide, nil, []Modification{Oxidation}, 0); len(isoforms) != 1 || len(isoforms[0]) != 0 {
+		t.Errorf("Error: ModificationSites() without variable sites = %+v", isoforms)
+	}
+
+	if mod, err := LookupModification("oxidation"); err != nil || mod.Mass != 15.994915 {
+		t.Errorf("Error: LookupModification(oxidation) = %+v, %v", mod, err)
+	}
+	if _, err := LookupModification("Methyl"); err == nil {
+		t.Errorf("Error: LookupModification(Methyl) expected an error")
+	}
+}
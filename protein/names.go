@@ -0,0 +1,75 @@
+package protein
+
+import (
+	"fmt"
+	"strings"
+)
+
+// aminoAcidNames maps the lower case three letter codes and names of the amino acids to Protein.
+var aminoAcidNames = map[string]Protein{
+	// IUPAC spellings that put the locant first, and common synonyms
+	"4abu": Abu4, "3hyp": Hyp3, "4hyp": Hyp4, "sar": MeGly, "sarcosine": MeGly, "gaba": Abu4,
+}
+
+// indexNames adds the codes and names of aminoAcidProperties to aminoAcidNames.
+func indexNames() {
+	for aa, p := range aminoAcidProperties {
+		if aa == Gap {
+			continue
+		}
+		aminoAcidNames[strings.ToLower(p.Abbreviation)] = aa
+		aminoAcidNames[strings.ToLower(p.Name)] = aa
+	}
+}
+
+// ParseName converts a three letter code or full name, such as Hyp4 or 4-Hydroxyproline, to its
+// amino acid. Case is ignored.
+func ParseName(name string) (Protein, error) {
+	if aa, ok := aminoAcidNames[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return aa, nil
+	}
+	return Unknown, fmt.Errorf("unknown amino acid %q", name)
+}
+
+// Abbreviation returns the three letter code of the amino acid, or Xaa for Unknown.
+func (aa Protein) Abbreviation() string {
+	if p, ok := aa.Properties(); ok {
+		return p.Abbreviation
+	}
+	return "Xaa"
+}
+
+// Name returns the full name of the amino acid, e.g. 4-Hydroxyproline.
+func (aa Protein) Name() string {
+	if p, ok := aa.Properties(); ok {
+		return p.Name
+	}
+	return "Unknown"
+}
+
+// ToThreeLetter formats a sequence with three letter codes separated by hyphens, e.g. Ala-Hyp4-Gly.
+func ToThreeLetter(proteins []Protein) string {
+	codes := make([]string, len(proteins))
+	for i, aa := range proteins {
+		codes[i] = aa.Abbreviation()
+	}
+	return strings.Join(codes, "-")
+}
+
+// ParseThreeLetter parses a sequence of hyphen separated three letter codes, e.g. Ala-Hyp4-Gly,
+// into Protein amino acids. Unusual amino acids are accepted by the names of their constants.
+func ParseThreeLetter(text string) ([]Protein, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	codes := strings.Split(text, "-")
+	proteins := make([]Protein, len(codes))
+	for i, code := range codes {
+		aa, err := ParseName(code)
+		if err != nil {
+			return nil, fmt.Errorf("residue %d: %v", i+1, err)
+		}
+		proteins[i] = aa
+	}
+	return proteins, nil
+}
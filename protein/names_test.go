@@ -0,0 +1,50 @@
+package protein
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Protein
+	}{
+		{"Ala", Ala}, {"ala", Ala}, {"Alanine", Ala}, {"Ter", Stop}, {"Xle", Xle},
+		{"Hyp4", Hyp4}, {"4Hyp", Hyp4}, {"4-Hydroxyproline", Hyp4}, {"aIle", aIle}, {"Sar", MeGly},
+	}
+	for _, test := range tests {
+		if aa, err := ParseName(test.name); err != nil || aa != test.expected {
+			t.Errorf("Error: ParseName(%s) = %s, %v, expected: %s", test.name, aa.Abbreviation(), err, test.expected.Abbreviation())
+		}
+	}
+	if _, err := ParseName("Foo"); err == nil {
+		t.Errorf("Error: ParseName(Foo) expected an error")
+	}
+	if Orn.Name() != "Ornithine" || Unknown.Abbreviation() != "Xaa" {
+		t.Errorf("Error: Orn.Name() = %s, Unknown.Abbreviation() = %s", Orn.Name(), Unknown.Abbreviation())
+	}
+}
+
+func TestThreeLetter(t *testing.T) {
+	seq, err := ParseThreeLetter("Ala-Hyp4-Gly")
+	if err != nil || !Equal(seq, []Protein{Ala, Hyp4, Gly}) {
+		t.Fatalf("Error: ParseThreeLetter(Ala-Hyp4-Gly) = %v, %v", seq, err)
+	}
+	if text := ToThreeLetter(seq); text != "Ala-Hyp4-Gly" {
+		t.Errorf("Error: ToThreeLetter() = %s, expected: Ala-Hyp4-Gly", text)
+	}
+	if text := ToString(seq); text != "AXG" {
+		t.Errorf("Error: ToString() = %s, expected: AXG", text)
+	}
+	// Hydroxyproline adds one oxygen to proline
+	if weight := MolecularWeight(seq) - MolecularWeight(ToProteins("APG")); math.Abs(weight-15.9994) > 1e-3 {
+		t.Errorf("Error: MolecularWeight() difference = %f, expected: 15.9994", weight)
+	}
+	if _, err := ParseThreeLetter("Ala-Foo"); err == nil || err.Error() != `residue 2: unknown amino acid "Foo"` {
+		t.Errorf("Error: ParseThreeLetter(Ala-Foo) error = %v", err)
+	}
+	if seq, err := ParseThreeLetter(""); seq != nil || err != nil {
+		t.Errorf("Error: ParseThreeLetter(\"\") = %v, %v", seq, err)
+	}
+}
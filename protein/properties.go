@@ -0,0 +1,177 @@
+package protein
+
+// Polarity classifies amino acid side chains.
+type Polarity int
+
+const (
+	Unclassified Polarity = iota // Stop, gaps and ambiguity codes that span several classes
+	Nonpolar                     // Hydrophobic side chains
+	Polar                        // Uncharged polar side chains
+	Acidic                       // Negatively charged at neutral pH
+	Basic                        // Positively charged or titratable near neutral pH
+)
+
+// String returns the lower case name of the class.
+func (p Polarity) String() string {
+	switch p {
+	case Nonpolar:
+		return "nonpolar"
+	case Polar:
+		return "polar"
+	case Acidic:
+		return "acidic"
+	case Basic:
+		return "basic"
+	}
+	return "unclassified"
+}
+
+// Masses of water, added once to the residue masses of a peptide for its free termini.
+const (
+	WaterMonoisotopicMass = 18.010565
+	WaterAverageMass      = 18.01524
+)
+
+// Default pKa values of the free termini on the Bjellqvist scale used by ExPASy ProtParam.
+// Properties.NTermPKa and Properties.CTermPKa override them for some terminal residues.
+const (
+	NTermPKa = 7.5
+	CTermPKa = 3.55
+)
+
+// Properties holds the chemistry of one amino acid residue. Masses are residue masses, that is
+// the mass of the free amino acid minus water, in daltons. PKa is the pKa of the side chain on
+// the Bjellqvist scale, or 0 when the side chain is not ionizable.
+type Properties struct {
+	Code             byte   // One letter code
+	Abbreviation     string // Three letter code
+	Name             string
+	MonoisotopicMass float64
+	AverageMass      float64
+	PKa              float64
+	NTermPKa         float64 // pKa of the amino group when the residue is N-terminal
+	CTermPKa         float64 // pKa of the carboxyl group when the residue is C-terminal
+	KyteDoolittle    float64 // Hydropathy of Kyte and Doolittle (1982)
+	HoppWoods        float64 // Hydrophilicity of Hopp and Woods (1981)
+	Eisenberg        float64 // Normalized consensus hydrophobicity of Eisenberg et al. (1984)
+	Charge           float64 // Charge of the side chain at neutral pH
+	Polarity         Polarity
+}
+
+// aminoAcidProperties holds the properties of the standard and the two genetically encoded
+// non-standard amino acids, and the masses of the unusual amino acids. Sec and Pyl have no
+// published hydropathy values and keep zero.
+var aminoAcidProperties = map[Protein]Properties{
+	Ala:  {'A', "Ala", "Alanine", 71.03711, 71.0788, 0, 7.59, CTermPKa, 1.8, -0.5, 0.62, 0, Nonpolar},
+	Arg:  {'R', "Arg", "Arginine", 156.10111, 156.1875, 12.0, NTermPKa, CTermPKa, -4.5, 3.0, -2.53, 1, Basic},
+	Asn:  {'N', "Asn", "Asparagine", 114.04293, 114.1038, 0, NTermPKa, CTermPKa, -3.5, 0.2, -0.78, 0, Polar},
+	Asp:  {'D', "Asp", "Aspartic acid", 115.02694, 115.0886, 4.05, NTermPKa, 4.55, -3.5, 3.0, -0.90, -1, Acidic},
+	Cys:  {'C', "Cys", "Cysteine", 103.00919, 103.1388, 9.0, NTermPKa, CTermPKa, 2.5, -1.0, 0.29, 0, Polar},
+	Gln:  {'Q', "Gln", "Glutamine", 128.05858, 128.1307, 0, NTermPKa, CTermPKa, -3.5, 0.2, -0.85, 0, Polar},
+	Glu:  {'E', "Glu", "Glutamic acid", 129.04259, 129.1155, 4.45, 7.7, 4.75, -3.5, 3.0, -0.74, -1, Acidic},
+	Gly:  {'G', "Gly", "Glycine", 57.02146, 57.0519, 0, NTermPKa, CTermPKa, -0.4, 0.0, 0.48, 0, Nonpolar},
+	His:  {'H', "His", "Histidine", 137.05891, 137.1411, 5.98, NTermPKa, CTermPKa, -3.2, -0.5, -0.40, 0, Basic},
+	Ile:  {'I', "Ile", "Isoleucine", 113.08406, 113.1594, 0, NTermPKa, CTermPKa, 4.5, -1.8, 1.38, 0, Nonpolar},
+	Leu:  {'L', "Leu", "Leucine", 113.08406, 113.1594, 0, NTermPKa, CTermPKa, 3.8, -1.8, 1.06, 0, Nonpolar},
+	Lys:  {'K', "Lys", "Lysine", 128.09496, 128.1741, 10.0, NTermPKa, CTermPKa, -3.9, 3.0, -1.50, 1, Basic},
+	Met:  {'M', "Met", "Methionine", 131.04049, 131.1926, 0, 7.0, CTermPKa, 1.9, -1.3, 0.64, 0, Nonpolar},
+	Phe:  {'F', "Phe", "Phenylalanine", 147.06841, 147.1766, 0, NTermPKa, CTermPKa, 2.8, -2.5, 1.19, 0, Nonpolar},
+	Pro:  {'P', "Pro", "Proline", 97.05276, 97.1167, 0, 8.36, CTermPKa, -1.6, 0.0, 0.12, 0, Nonpolar},
+	Pyl:  {'O', "Pyl", "Pyrrolysine", 237.14773, 237.2982, 0, NTermPKa, CTermPKa, 0, 0, 0, 0, Polar},
+	Ser:  {'S', "Ser", "Serine", 87.03203, 87.0782, 0, 6.93, CTermPKa, -0.8, 0.3, -0.18, 0, Polar},
+	Sec:  {'U', "Sec", "Selenocysteine", 150.95364, 150.0379, 5.43, NTermPKa, CTermPKa, 0, 0, 0, 0, Polar},
+	Thr:  {'T', "Thr", "Threonine", 101.04768, 101.1051, 0, 6.82, CTermPKa, -0.7, -0.4, -0.05, 0, Polar},
+	Trp:  {'W', "Trp", "Tryptophan", 186.07931, 186.2132, 0, NTermPKa, CTermPKa, -0.9, -3.4, 0.81, 0, Nonpolar},
+	Tyr:  {'Y', "Tyr", "Tyrosine", 163.06333, 163.1760, 10.0, NTermPKa, CTermPKa, -1.3, -2.3, 0.26, 0, Polar},
+	Val:  {'V', "Val", "Valine", 99.06841, 99.1326, 0, 7.44, CTermPKa, 4.2, -1.5, 1.08, 0, Nonpolar},
+	Stop: {Code: '*', Abbreviation: "Ter", Name: "Termination"},
+	Gap:  {Code: '-', Abbreviation: "---", Name: "Gap"},
+
+	// Unusual amino acids have no one letter code and only carry names and masses
+	Aad:   {Abbreviation: "Aad", Name: "2-Aminoadipic acid", MonoisotopicMass: 143.05824, AverageMass: 143.1424},
+	bAad:  {Abbreviation: "bAad", Name: "3-Aminoadipic acid", MonoisotopicMass: 143.05824, AverageMass: 143.1424},
+	bAla:  {Abbreviation: "bAla", Name: "beta-Alanine", MonoisotopicMass: 71.03711, AverageMass: 71.0788},
+	Abu:   {Abbreviation: "Abu", Name: "2-Aminobutyric acid", MonoisotopicMass: 85.05276, AverageMass: 85.1057},
+	Abu4:  {Abbreviation: "Abu4", Name: "4-Aminobutyric acid", MonoisotopicMass: 85.05276, AverageMass: 85.1057},
+	Acp:   {Abbreviation: "Acp", Name: "6-Aminocaproic acid", MonoisotopicMass: 113.08406, AverageMass: 113.1595},
+	Ahe:   {Abbreviation: "Ahe", Name: "2-Aminoheptanoic acid", MonoisotopicMass: 127.09971, AverageMass: 127.1864},
+	Aib:   {Abbreviation: "Aib", Name: "2-Aminoisobutyric acid", MonoisotopicMass: 85.05276, AverageMass: 85.1057},
+	bAib:  {Abbreviation: "bAib", Name: "3-Aminoisobutyric acid", MonoisotopicMass: 85.05276, AverageMass: 85.1057},
+	Apm:   {Abbreviation: "Apm", Name: "2-Aminopimelic acid", MonoisotopicMass: 157.07389, AverageMass: 157.1693},
+	Dbu:   {Abbreviation: "Dbu", Name: "2,4-Diaminobutyric acid", MonoisotopicMass: 100.06366, AverageMass: 100.1204},
+	Des:   {Abbreviation: "Des", Name: "Desmosine", MonoisotopicMass: 508.27712, AverageMass: 508.5952},
+	Dpm:   {Abbreviation: "Dpm", Name: "2,2'-Diaminopimelic acid", MonoisotopicMass: 172.08479, AverageMass: 172.1840},
+	Dpr:   {Abbreviation: "Dpr", Name: "2,3-Diaminopropionic acid", MonoisotopicMass: 86.04801, AverageMass: 86.0935},
+	EtGly: {Abbreviation: "EtGly", Name: "N-Ethylglycine", MonoisotopicMass: 85.05276, AverageMass: 85.1057},
+	EtAsn: {Abbreviation: "EtAsn", Name: "N-Ethylasparagine", MonoisotopicMass: 142.07423, AverageMass: 142.1577},
+	Hyl:   {Abbreviation: "Hyl", Name: "Hydroxylysine", MonoisotopicMass: 144.08988, AverageMass: 144.1736},
+	aHyl:  {Abbreviation: "aHyl", Name: "allo-Hydroxylysine", MonoisotopicMass: 144.08988, AverageMass: 144.1736},
+	Hyp3:  {Abbreviation: "Hyp3", Name: "3-Hydroxyproline", MonoisotopicMass: 113.04768, AverageMass: 113.1161},
+	Hyp4:  {Abbreviation: "Hyp4", Name: "4-Hydroxyproline", MonoisotopicMass: 113.04768, AverageMass: 113.1161},
+	Ide:   {Abbreviation: "Ide", Name: "Isodesmosine", MonoisotopicMass: 508.27712, AverageMass: 508.5952},
+	aIle:  {Abbreviation: "aIle", Name: "allo-Isoleucine", MonoisotopicMass: 113.08406, AverageMass: 113.1595},
+	MeGly: {Abbreviation: "MeGly", Name: "N-Methylglycine", MonoisotopicMass: 71.03711, AverageMass: 71.0788},
+	MeIle: {Abbreviation: "MeIle", Name: "N-Methylisoleucine", MonoisotopicMass: 127.09971, AverageMass: 127.1864},
+	MeLys: {Abbreviation: "MeLys", Name: "6-N-Methyllysine", MonoisotopicMass: 142.11061, AverageMass: 142.2010},
+	MeVal: {Abbreviation: "MeVal", Name: "N-Methylvaline", MonoisotopicMass: 113.08406, AverageMass: 113.1595},
+	Nva:   {Abbreviation: "Nva", Name: "Norvaline", MonoisotopicMass: 99.06841, AverageMass: 99.1326},
+	Nle:   {Abbreviation: "Nle", Name: "Norleucine", MonoisotopicMass: 113.08406, AverageMass: 113.1595},
+	Orn:   {Abbreviation: "Orn", Name: "Ornithine", MonoisotopicMass: 114.07931, AverageMass: 114.1473},
+}
+
+// ambiguityCodes maps each ambiguity code to the residues it stands for.
+var ambiguityCodes = map[Protein][]Protein{
+	Asx: {Asn, Asp},
+	Glx: {Gln, Glu},
+	Xle: {Leu, Ile},
+	Xaa: {Ala, Arg, Asn, Asp, Cys, Gln, Glu, Gly, His, Ile, Leu, Lys, Met, Phe, Pro, Ser, Thr, Trp, Tyr, Val},
+}
+
+func init() {
+	names := map[Protein][3]string{
+		Asx: {"B", "Asx", "Asparagine or aspartic acid"},
+		Glx: {"Z", "Glx", "Glutamine or glutamic acid"},
+		Xle: {"J", "Xle", "Leucine or isoleucine"},
+		Xaa: {"X", "Xaa", "Any amino acid"},
+	}
+	// Ambiguity codes take the mean masses and hydropathy of their residues and are treated as
+	// not ionizable
+	for code, residues := range ambiguityCodes {
+		p := Properties{Code: names[code][0][0], Abbreviation: names[code][1], Name: names[code][2],
+			NTermPKa: NTermPKa, CTermPKa: CTermPKa, Polarity: aminoAcidProperties[residues[0]].Polarity}
+		for _, aa := range residues {
+			r := aminoAcidProperties[aa]
+			p.MonoisotopicMass += r.MonoisotopicMass / float64(len(residues))
+			p.AverageMass += r.AverageMass / float64(len(residues))
+			p.KyteDoolittle += r.KyteDoolittle / float64(len(residues))
+			p.HoppWoods += r.HoppWoods / float64(len(residues))
+			p.Eisenberg += r.Eisenberg / float64(len(residues))
+			if r.Polarity != p.Polarity {
+				p.Polarity = Unclassified
+			}
+		}
+		aminoAcidProperties[code] = p
+	}
+	indexNames()
+}
+
+// Properties returns the properties of the amino acid and whether it has any. Unknown has none.
+func (aa Protein) Properties() (Properties, bool) {
+	p, ok := aminoAcidProperties[aa]
+	return p, ok
+}
+
+// Expand returns the residues an ambiguity code stands for (Asx, Glx, Xle or Xaa), or the amino
+// acid itself.
+func (aa Protein) Expand() []Protein {
+	if residues, ok := ambiguityCodes[aa]; ok {
+		return append([]Protein(nil), residues...)
+	}
+	return []Protein{aa}
+}
+
+// IsAmbiguous reports whether the amino acid is an ambiguity code.
+func (aa Protein) IsAmbiguous() bool {
+	_, ok := ambiguityCodes[aa]
+	return ok
+}
@@ -0,0 +1,57 @@
+package protein
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProperties(t *testing.T) {
+	for _, aa := range ToProteins("ARNDCQEGHILKMFPSTWYVUO") {
+		p, ok := aa.Properties()
+		if !ok || Protein(p.Code) != aa || len(p.Abbreviation) != 3 || p.Name == "" || p.MonoisotopicMass <= 0 || p.AverageMass <= 0 {
+			t.Errorf("Error: %c.Properties() = %+v, %v", aa, p, ok)
+		}
+		if math.Abs(p.AverageMass-p.MonoisotopicMass) > 1 {
+			t.Errorf("Error: %s average mass %f is far from monoisotopic mass %f", p.Abbreviation, p.AverageMass, p.MonoisotopicMass)
+		}
+	}
+
+	// Glycine is the smallest residue, C2H3NO
+	if p, _ := Gly.Properties(); p.MonoisotopicMass != 57.02146 || p.Polarity != Nonpolar || p.Polarity.String() != "nonpolar" {
+		t.Errorf("Error: Gly.Properties() = %+v", p)
+	}
+	if p, _ := Asp.Properties(); p.Charge != -1 || p.PKa != 4.05 || p.CTermPKa != 4.55 || p.Polarity != Acidic {
+		t.Errorf("Error: Asp.Properties() = %+v", p)
+	}
+	if p, _ := Lys.Properties(); p.Charge != 1 || p.KyteDoolittle != -3.9 || p.HoppWoods != 3.0 || p.Eisenberg != -1.50 {
+		t.Errorf("Error: Lys.Properties() = %+v", p)
+	}
+	if p, ok := Orn.Properties(); !ok || p.Code != 0 || p.MonoisotopicMass != 114.07931 || p.Abbreviation != "Orn" {
+		t.Errorf("Error: Orn.Properties() = %+v, %v", p, ok)
+	}
+	if _, ok := Unknown.Properties(); ok {
+		t.Errorf("Error: Unknown.Properties() expected no properties")
+	}
+}
+
+func TestAmbiguityCodes(t *testing.T) {
+	if residues := Asx.Expand(); !Equal(residues, []Protein{Asn, Asp}) || !Asx.IsAmbiguous() {
+		t.Errorf("Error: Asx.Expand() = %s", ToString(residues))
+	}
+	if residues := Xaa.Expand(); len(residues) != 20 {
+		t.Errorf("Error: Xaa.Expand() returned %d residues", len(residues))
+	}
+	if residues := Met.Expand(); !Equal(residues, []Protein{Met}) || Met.IsAmbiguous() {
+		t.Errorf("Error: Met.Expand() = %s", ToString(residues))
+	}
+
+	xle, _ := Xle.Properties()
+	leu, _ := Leu.Properties()
+	if xle.MonoisotopicMass != leu.MonoisotopicMass || xle.Abbreviation != "Xle" || xle.Polarity != Nonpolar {
+		t.Errorf("Error: Xle.Properties() = %+v", xle)
+	}
+	asx, _ := Asx.Properties()
+	if math.Abs(asx.AverageMass-(114.1038+115.0886)/2) > 1e-9 || asx.PKa != 0 || asx.Polarity != Unclassified {
+		t.Errorf("Error: Asx.Properties() = %+v", asx)
+	}
+}
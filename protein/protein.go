@@ -2,9 +2,6 @@ package protein
 
 import (
 	"fmt"
-	"strings"
-
-	"gopher-proteinlab/parseio"
 )
 
 // Protein amino acid byte
@@ -100,25 +97,22 @@ func ByteToAminoAcid(b byte) (Protein, error) {
 	return Unknown, fmt.Errorf("Error: '%s' is an invalid amino acid symbol. Ensure the input contains valid characters.", string(b))
 }
 
-// ToProteins converts string to a slice of Protein amino acids.
+// ToProteins converts string to a slice of Protein amino acids. Lower case one letter codes are
+// accepted and other characters become Xaa; use ParseProteins to have them reported instead.
 func ToProteins(text string) []Protein {
-	var proteins []Protein = make([]Protein, len(text))
-
-	for i := 0; i < len(text); i++ {
-		if aa, err := ByteToAminoAcid(text[i]); parseio.ExitOnError(err) {
-			proteins[i] = aa
-		}
-	}
+	proteins, _ := Encode(text, EncodeOptions{FoldCase: true, ReplaceUnknown: true})
 	return proteins
 }
 
+// ParseProteins converts upper or lower case one letter codes to Protein amino acids, returning
+// an *InvalidResidueError that lists every other character.
+func ParseProteins(text string) ([]Protein, error) {
+	return Encode(text, EncodeOptions{FoldCase: true})
+}
+
 // ToString converts a slice of Protein to a string.
 func ToString(proteins []Protein) string {
-	var words strings.Builder
-	for _, aa := range proteins {
-		parseio.ExitOnError(words.WriteByte(ProteinToByteMap[aa]))
-	}
-	return words.String()
+	return Decode(proteins)
 }
 
 // Equal asserts if two slices of Protein amino acids are equal.
@@ -38,6 +38,19 @@ func TestToProteinSequence(t *testing.T) {
 	}
 }
 
+func TestLowerCaseProteins(t *testing.T) {
+	if text := ToString(ToProteins("mkvLLA")); text != "MKVLLA" {
+		t.Errorf("Error: ToString(ToProteins(mkvLLA)) = %s, expected: MKVLLA", text)
+	}
+	if proteins, err := ParseProteins("mkvLLA"); err != nil || ToString(proteins) != "MKVLLA" {
+		t.Errorf("Error: ParseProteins(mkvLLA) = %s, %v, expected: MKVLLA", ToString(proteins), err)
+	}
+	_, err := ParseProteins("mk1L A")
+	if invalid, ok := err.(*InvalidResidueError); !ok || len(invalid.Positions) != 2 || invalid.Positions[0] != 3 || invalid.Positions[1] != 5 {
+		t.Errorf("Error: ParseProteins(mk1L A) error = %v, expected positions 3 and 5", err)
+	}
+}
+
 func TestProteinsToString(t *testing.T) {
 	for _, test := range testcases {
 		if ToString(test.expected) != test.txt {
@@ -0,0 +1,281 @@
+package protein
+
+import "math"
+
+// Molar extinction coefficients at 280 nm in M-1 cm-1 of Pace et al. (1995), as used by ExPASy
+// ProtParam.
+const (
+	TrpExtinction     = 5500
+	TyrExtinction     = 1490
+	CystineExtinction = 125
+)
+
+// ProtParam holds the physicochemical parameters ExPASy ProtParam reports for a protein.
+type ProtParam struct {
+	Length                int
+	MolecularWeight       float64 // Average mass in daltons
+	MonoisotopicMass      float64
+	IsoelectricPoint      float64
+	ExtinctionCoefficient float64 // At 280 nm in M-1 cm-1, assuming every pair of Cys forms a cystine
+	ExtinctionReduced     float64 // At 280 nm in M-1 cm-1, assuming all Cys are reduced
+	Absorbance            float64 // Absorbance at 280 nm of a 1 g/l solution, assuming cystines
+	AbsorbanceReduced     float64 // Absorbance at 280 nm of a 1 g/l solution, assuming reduced Cys
+	InstabilityIndex      float64
+	AliphaticIndex        float64
+	Gravy                 float64
+	Composition           map[Protein]int
+}
+
+// Analyze computes the ProtParam parameters of a protein sequence. Stop codons and gaps are not
+// part of the protein and are ignored throughout.
+func Analyze(seq []Protein) ProtParam {
+	seq = residues(seq)
+	return ProtParam{
+		Length:                len(seq),
+		MolecularWeight:       MolecularWeight(seq),
+		MonoisotopicMass:      MonoisotopicMass(seq),
+		IsoelectricPoint:      IsoelectricPoint(seq),
+		ExtinctionCoefficient: ExtinctionCoefficient(seq, true),
+		ExtinctionReduced:     ExtinctionCoefficient(seq, false),
+		Absorbance:            Absorbance280(seq, true),
+		AbsorbanceReduced:     Absorbance280(seq, false),
+		InstabilityIndex:      InstabilityIndex(seq),
+		AliphaticIndex:        AliphaticIndex(seq),
+		Gravy:                 Gravy(seq),
+		Composition:           Composition(seq),
+	}
+}
+
+// residues returns the sequence without stop codons and gaps.
+func residues(seq []Protein) []Protein {
+	for _, aa := range seq {
+		if aa == Stop || aa == Gap {
+			filtered := make([]Protein, 0, len(seq))
+			for _, aa := range seq {
+				if aa != Stop && aa != Gap {
+					filtered = append(filtered, aa)
+				}
+			}
+			return filtered
+		}
+	}
+	return seq
+}
+
+// MolecularWeight returns the average mass of the protein in daltons, the sum of its residue masses
+// plus one water for the free termini. Residues without properties add no mass.
+func MolecularWeight(seq []Protein) float64 {
+	seq = residues(seq)
+	if len(seq) == 0 {
+		return 0
+	}
+	mass := WaterAverageMass
+	for _, aa := range seq {
+		p, _ := aa.Properties()
+		mass += p.AverageMass
+	}
+	return mass
+}
+
+// MonoisotopicMass returns the monoisotopic mass of the protein in daltons.
+func MonoisotopicMass(seq []Protein) float64 {
+	seq = residues(seq)
+	if len(seq) == 0 {
+		return 0
+	}
+	mass := WaterMonoisotopicMass
+	for _, aa := range seq {
+		p, _ := aa.Properties()
+		mass += p.MonoisotopicMass
+	}
+	return mass
+}
+
+// NetCharge returns the charge of the protein at the given pH by the Henderson-Hasselbalch
+// equation with the pKa values of Bjellqvist et al. (1993). Basic side chains and the N-terminus
+// carry positive charges, the other ionizable side chains and the C-terminus negative ones.
+func NetCharge(seq []Protein, pH float64) float64 {
+	seq = residues(seq)
+	if len(seq) == 0 {
+		return 0
+	}
+	first, _ := seq[0].Properties()
+	last, _ := seq[len(seq)-1].Properties()
+	nTerm, cTerm := NTermPKa, CTermPKa
+	if first.NTermPKa != 0 {
+		nTerm = first.NTermPKa
+	}
+	if last.CTermPKa != 0 {
+		cTerm = last.CTermPKa
+	}
+
+	charge := positiveCharge(nTerm, pH) - negativeCharge(cTerm, pH)
+	for _, aa := range seq {
+		p, _ := aa.Properties()
+		switch {
+		case p.PKa == 0:
+		case p.Polarity == Basic:
+			charge += positiveCharge(p.PKa, pH)
+		default:
+			charge -= negativeCharge(p.PKa, pH)
+		}
+	}
+	return charge
+}
+
+// positiveCharge returns the fraction of a basic group that is protonated at pH.
+func positiveCharge(pKa, pH float64) float64 {
+	return 1 / (1 + math.Pow(10, pH-pKa))
+}
+
+// negativeCharge returns the fraction of an acidic group that is deprotonated at pH.
+func negativeCharge(pKa, pH float64) float64 {
+	return 1 / (1 + math.Pow(10, pKa-pH))
+}
+
+// IsoelectricPoint returns the pH at which the net charge of the protein is zero, found by
+// bisection between pH 0 and 14 to a precision of 0.0001.
+func IsoelectricPoint(seq []Protein) float64 {
+	seq = residues(seq)
+	low, high := 0.0, 14.0
+	for high-low > 0.0001 {
+		pH := (low + high) / 2
+		if NetCharge(seq, pH) > 0 {
+			low = pH
+		} else {
+			high = pH
+		}
+	}
+	return (low + high) / 2
+}
+
+// ExtinctionCoefficient returns the molar extinction coefficient of the protein at 280 nm in
+// M-1 cm-1. When cystines is true every pair of Cys residues is assumed to form a disulfide bond.
+func ExtinctionCoefficient(seq []Protein, cystines bool) float64 {
+	var trp, tyr, cys int
+	for _, aa := range seq {
+		switch aa {
+		case Trp:
+			trp++
+		case Tyr:
+			tyr++
+		case Cys:
+			cys++
+		}
+	}
+	coefficient := trp*TrpExtinction + tyr*TyrExtinction
+	if cystines {
+		coefficient += cys / 2 * CystineExtinction
+	}
+	return float64(coefficient)
+}
+
+// Absorbance280 returns the absorbance at 280 nm of a 1 g/l solution of the protein, its
+// extinction coefficient divided by its molecular weight.
+func Absorbance280(seq []Protein, cystines bool) float64 {
+	weight := MolecularWeight(seq)
+	if weight == 0 {
+		return 0
+	}
+	return ExtinctionCoefficient(seq, cystines) / weight
+}
+
+// InstabilityIndex returns the instability index of Guruprasad et al. (1990), the summed
+// instability weights of all dipeptides scaled by 10 over the length. Proteins scoring above 40
+// are predicted to be unstable in the test tube. Dipeptides with residues outside the 20 standard
+// amino acids add nothing.
+func InstabilityIndex(seq []Protein) float64 {
+	seq = residues(seq)
+	if len(seq) == 0 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i < len(seq); i++ {
+		if weights, ok := dipeptideInstability[seq[i-1]]; ok {
+			if j := instabilityColumns[seq[i]]; j > 0 {
+				sum += weights[j-1]
+			}
+		}
+	}
+	return 10 * sum / float64(len(seq))
+}
+
+// AliphaticIndex returns the aliphatic index of Ikai (1980), the relative volume occupied by the
+// aliphatic side chains of Ala, Val, Ile and Leu.
+func AliphaticIndex(seq []Protein) float64 {
+	seq = residues(seq)
+	if len(seq) == 0 {
+		return 0
+	}
+	var ala, val, ile, leu float64
+	for _, aa := range seq {
+		switch aa {
+		case Ala:
+			ala++
+		case Val:
+			val++
+		case Ile:
+			ile++
+		case Leu:
+			leu++
+		}
+	}
+	return 100 * (ala + 2.9*val + 3.9*(ile+leu)) / float64(len(seq))
+}
+
+// Gravy returns the grand average of hydropathy, the mean Kyte-Doolittle hydropathy of the
+// residues.
+func Gravy(seq []Protein) float64 {
+	seq = residues(seq)
+	if len(seq) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, aa := range seq {
+		p, _ := aa.Properties()
+		sum += p.KyteDoolittle
+	}
+	return sum / float64(len(seq))
+}
+
+// Composition counts the residues of each amino acid in the protein.
+func Composition(seq []Protein) map[Protein]int {
+	counts := make(map[Protein]int)
+	for _, aa := range residues(seq) {
+		counts[aa]++
+	}
+	return counts
+}
+
+// instabilityColumns maps the second residue of a dipeptide to its 1-based column in
+// dipeptideInstability.
+var instabilityColumns = map[Protein]int{
+	Ala: 1, Cys: 2, Asp: 3, Glu: 4, Phe: 5, Gly: 6, His: 7, Ile: 8, Lys: 9, Leu: 10,
+	Met: 11, Asn: 12, Pro: 13, Gln: 14, Arg: 15, Ser: 16, Thr: 17, Val: 18, Trp: 19, Tyr: 20,
+}
+
+// dipeptideInstability holds the dipeptide instability weight values (DIWV) of Guruprasad et al.
+// (1990), by first residue, with the second residue in the column order A C D E F G H I K L M N
+// P Q R S T V W Y.
+var dipeptideInstability = map[Protein][20]float64{
+	Ala: {1, 44.94, -7.49, 1, 1, 1, -7.49, 1, 1, 1, 1, 1, 20.26, 1, 1, 1, 1, 1, 1, 1},
+	Cys: {1, 1, 20.26, 1, 1, 1, 33.60, 1, 1, 20.26, 33.60, 1, 20.26, -6.54, 1, 1, 33.60, -6.54, 24.68, 1},
+	Asp: {1, 1, 1, 1, -6.54, 1, 1, 1, -7.49, 1, 1, 1, 1, 1, -6.54, 20.26, -14.03, 1, 1, 1},
+	Glu: {1, 44.94, 20.26, 33.60, 1, 1, -6.54, 20.26, 1, 1, 1, 1, 20.26, 20.26, 1, 20.26, 1, 1, -14.03, 1},
+	Phe: {1, 1, 13.34, 1, 1, 1, 1, 1, -14.03, 1, 1, 1, 20.26, 1, 1, 1, 1, 1, 1, 33.60},
+	Gly: {-7.49, 1, 1, -6.54, 1, 13.34, 1, -7.49, -7.49, 1, 1, -7.49, 1, 1, 1, 1, -7.49, 1, 13.34, -7.49},
+	His: {1, 1, 1, 1, -9.37, -9.37, 1, 44.94, 24.68, 1, 1, 24.68, -1.88, 1, 1, 1, -6.54, 1, -1.88, 44.94},
+	Ile: {1, 1, 1, 44.94, 1, 1, 13.34, 1, -7.49, 20.26, 1, 1, -1.88, 1, 1, 1, 1, -7.49, 1, 1},
+	Lys: {1, 1, 1, 1, 1, -7.49, 1, -7.49, 1, -7.49, 33.60, 1, -6.54, 24.64, 33.60, 1, 1, -7.49, 1, 1},
+	Leu: {1, 1, 1, 1, 1, 1, 1, 1, -7.49, 1, 1, 1, 20.26, 33.60, 20.26, 1, 1, 1, 24.68, 1},
+	Met: {13.34, 1, 1, 1, 1, 1, 58.28, 1, 1, 1, -1.88, 1, 44.94, -6.54, -6.54, 44.94, -1.88, 1, 1, 24.68},
+	Asn: {1, -1.88, 1, 1, -14.03, -14.03, 1, 44.94, 24.68, 1, 1, 1, -1.88, -6.54, 1, 1, -7.49, 1, -9.37, 1},
+	Pro: {20.26, -6.54, -6.54, 18.38, 20.26, 1, 1, 1, 1, 1, -6.54, 1, 20.26, 20.26, -6.54, 20.26, 1, 20.26, -1.88, 1},
+	Gln: {1, -6.54, 20.26, 20.26, -6.54, 1, 1, 1, 1, 1, 1, 1, 20.26, 20.26, 1, 44.94, 1, -6.54, 1, -6.54},
+	Arg: {1, 1, 1, 1, 1, -7.49, 20.26, 1, 1, 1, 1, 13.34, 20.26, 20.26, 58.28, 44.94, 1, 1, 58.28, -6.54},
+	Ser: {1, 33.60, 1, 20.26, 1, 1, 1, 1, 1, 1, 1, 1, 44.94, 20.26, 20.26, 20.26, 1, 1, 1, 1},
+	Thr: {1, 1, 1, 20.26, 13.34, -7.49, 1, 1, 1, 1, 1, -14.03, 1, -6.54, 1, 1, 1, 1, -14.03, 1},
+	Val: {1, 1, -14.03, 1, 1, -7.49, 1, 1, -1.88, 1, 1, 1, 20.26, 1, 1, 1, -7.49, 1, 1, -6.54},
+	Trp: {-14.03, 1, 1, 1, 1, -9.37, 24.68, 1, 1, 13.34, 24.68, 13.34, 1, 1, 1, 1, -14.03, -7.49, 1, 1},
+	Tyr: {24.68, 1, 24.68, -6.54, 1, -7.49, 13.34, 1, 1, 1, 44.94, 1, 13.34, 1, -15.91, 1, -7.49, 1, -9.37, 13.34},
+}
@@ -0,0 +1,59 @@
+package protein
+
+import (
+	"math"
+	"testing"
+)
+
+// ubiquitin is human ubiquitin, P0CG48 residues 1-76, with the values reported by ExPASy ProtParam.
+const ubiquitin = "MQIFVKTLTGKTITLEVEPSDTIENVKAKIQDKEGIPPDQQRLIFAGKQLEDGRTLSDYNIQKESTLHLVLRLRGG"
+
+func TestAnalyze(t *testing.T) {
+	p := Analyze(ToProteins(ubiquitin + "*"))
+	if p.Length != 76 || math.Abs(p.MolecularWeight-8564.84) > 0.01 || math.Abs(p.IsoelectricPoint-6.56) > 0.01 {
+		t.Errorf("Error: Analyze() = %+v, expected: length 76, weight 8564.84, pI 6.56", p)
+	}
+	if p.ExtinctionCoefficient != 1490 || p.ExtinctionReduced != 1490 || math.Abs(p.Absorbance-0.174) > 0.001 {
+		t.Errorf("Error: Analyze() extinction %f, %f, absorbance %f, expected: 1490, 1490, 0.174", p.ExtinctionCoefficient, p.ExtinctionReduced, p.Absorbance)
+	}
+	if math.Abs(p.Gravy+0.489) > 0.001 || p.AliphaticIndex != 100 {
+		t.Errorf("Error: Analyze() GRAVY %f, aliphatic index %f, expected: -0.489, 100", p.Gravy, p.AliphaticIndex)
+	}
+	if p.Composition[Leu] != 9 || p.Composition[Stop] != 0 {
+		t.Errorf("Error: Analyze() composition %v", p.Composition)
+	}
+}
+
+func TestNetCharge(t *testing.T) {
+	seq := ToProteins("KDEK")
+	expected := 2*positiveCharge(10, 7) + positiveCharge(NTermPKa, 7) - negativeCharge(4.05, 7) - negativeCharge(4.45, 7) - negativeCharge(CTermPKa, 7)
+	if charge := NetCharge(seq, 7); math.Abs(charge-expected) > 1e-9 {
+		t.Errorf("Error: NetCharge(KDEK, 7) = %f, expected: %f", charge, expected)
+	}
+	if charge := NetCharge(seq, 1); charge < 2.9 {
+		t.Errorf("Error: NetCharge(KDEK, 1) = %f, expected: about 3", charge)
+	}
+	if pI := IsoelectricPoint(ToProteins("KKKK")); pI < 10 {
+		t.Errorf("Error: IsoelectricPoint(KKKK) = %f, expected above 10", pI)
+	}
+}
+
+func TestExtinctionAndIndices(t *testing.T) {
+	seq := ToProteins("WYCCCA")
+	if e := ExtinctionCoefficient(seq, true); e != 5500+1490+125 {
+		t.Errorf("Error: ExtinctionCoefficient(cystines) = %f, expected: 7115", e)
+	}
+	if e := ExtinctionCoefficient(seq, false); e != 5500+1490 {
+		t.Errorf("Error: ExtinctionCoefficient(reduced) = %f, expected: 6990", e)
+	}
+	// Dipeptides AC and CA weigh 44.94 and 1
+	if ii := InstabilityIndex(ToProteins("ACA")); math.Abs(ii-10*45.94/3) > 1e-9 {
+		t.Errorf("Error: InstabilityIndex(ACA) = %f, expected: %f", ii, 10*45.94/3)
+	}
+	if ai := AliphaticIndex(ToProteins("AVIG")); math.Abs(ai-100*(1+2.9+3.9)/4) > 1e-9 {
+		t.Errorf("Error: AliphaticIndex(AVIG) = %f", ai)
+	}
+	if MolecularWeight(nil) != 0 || Gravy(nil) != 0 || InstabilityIndex(nil) != 0 {
+		t.Errorf("Error: empty sequences expected zero values")
+	}
+}
@@ -0,0 +1,90 @@
+package uniprot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopher-proteinlab/parseio"
+)
+
+// crc64Table is the table of the ISO 3309 polynomial used by UniProt and SWISS-PROT.
+var crc64Table = crc64.MakeTable(crc64.ISO)
+
+// CRC64 returns the CRC64 checksum of a sequence as computed by UniProt. Unlike hash/crc64 the
+// register starts at zero and the result is not inverted.
+func CRC64(sequence string) uint64 {
+	var crc uint64
+	for i := 0; i < len(sequence); i++ {
+		crc = crc64Table[byte(crc)^sequence[i]] ^ crc>>8
+	}
+	return crc
+}
+
+// ComputedChecksum returns the CRC64 checksum of the sequence as 16 upper case hex digits, the way
+// UniProt reports it in the checksum attribute.
+func (s Sequence) ComputedChecksum() string {
+	return fmt.Sprintf("%016X", CRC64(strings.Join(strings.Fields(s.Value), "")))
+}
+
+// massTolerance is the relative difference allowed between the declared and computed mass, which
+// covers rounding and small differences between residue mass tables. Differences of 1 dalton are
+// always accepted.
+const massTolerance = 1e-4
+
+// Mismatch is a sequence attribute of an entry that disagrees with the sequence itself.
+type Mismatch struct {
+	Accession string
+	Field     string // checksum, length or mass
+	Declared  string
+	Computed  string
+}
+
+// String formats the mismatch as "accession: field declared X, computed Y".
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s declared %s, computed %s", m.Accession, m.Field, m.Declared, m.Computed)
+}
+
+// VerifySequence checks the checksum, length and mass attributes of the sequence of the entry
+// against the sequence, to catch truncated or corrupted entries.
+func (e *Entry) VerifySequence() []Mismatch {
+	var mismatches []Mismatch
+	report := func(field, declared, computed string) {
+		mismatches = append(mismatches, Mismatch{Accession: e.Accession, Field: field, Declared: declared, Computed: computed})
+	}
+	s := e.Sequence
+	if checksum := s.ComputedChecksum(); !strings.EqualFold(checksum, s.Checksum) {
+		report("checksum", s.Checksum, checksum)
+	}
+	if length := len(s.Proteins()); length != s.Length {
+		report("length", strconv.Itoa(s.Length), strconv.Itoa(length))
+	}
+	if mass := s.ComputedMass(); math.Abs(float64(mass-s.Mass)) > math.Max(1, massTolerance*float64(s.Mass)) {
+		report("mass", strconv.Itoa(s.Mass), strconv.Itoa(mass))
+	}
+	return mismatches
+}
+
+// VerifyFile verifies the sequences of every entry of a UniProt XML file. The error is set when
+// the file cannot be parsed, e.g. because the download was cut short.
+func VerifyFile(filename string) ([]Mismatch, error) {
+	xmlReader := parseio.NewCodeReader(filename)
+	defer xmlReader.Close()
+
+	var mismatches []Mismatch
+	decoder := xml.NewDecoder(xmlReader)
+	for {
+		entry, err := ParseUniProt(decoder)
+		if err == io.EOF {
+			return mismatches, nil
+		}
+		if err != nil {
+			return mismatches, fmt.Errorf("%s: %v", filename, err)
+		}
+		mismatches = append(mismatches, entry.VerifySequence()...)
+	}
+}
@@ -0,0 +1,57 @@
+package uniprot
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+)
+
+func TestCRC64(t *testing.T) {
+	if crc := CRC64(""); crc != 0 {
+		t.Errorf("Error: CRC64(\"\") = %X, expected: 0", crc)
+	}
+	xmlReader := parseio.NewCodeReader("testdata/uniprot.xml.gz")
+	defer xmlReader.Close()
+	entry, err := ParseUniProt(xml.NewDecoder(xmlReader))
+	if err != nil {
+		t.Fatalf("ParseUniProt failed: %v", err)
+	}
+	if checksum := entry.Sequence.ComputedChecksum(); checksum != "C5E63C34B941711C" {
+		t.Errorf("Error: ComputedChecksum() = %s, expected: C5E63C34B941711C", checksum)
+	}
+	if mismatches := entry.VerifySequence(); len(mismatches) != 0 {
+		t.Errorf("Error: VerifySequence() = %v, expected no mismatches", mismatches)
+	}
+
+	// A sequence that lost its last residue disagrees on every attribute
+	entry.Sequence.Value = entry.Sequence.Value[:len(entry.Sequence.Value)-1]
+	mismatches := entry.VerifySequence()
+	if len(mismatches) != 3 || mismatches[1].String() != "P0C9F0: length declared 122, computed 121" {
+		t.Errorf("Error: VerifySequence() = %v, expected checksum, length and mass mismatches", mismatches)
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	if mismatches, err := VerifyFile("testdata/uniprot.xml.gz"); err != nil || len(mismatches) != 0 {
+		t.Errorf("Error: VerifyFile() = %v, %v, expected no mismatches", mismatches, err)
+	}
+
+	// A download cut short is reported as an error
+	xmlReader := parseio.NewCodeReader("testdata/uniprot.xml.gz")
+	data, err := io.ReadAll(xmlReader)
+	parseio.ExitOnError(err)
+	xmlReader.Close()
+	if tmpfile, err := os.CreateTemp("", "*.xml"); parseio.ExitOnError(err) {
+		defer os.Remove(tmpfile.Name())
+		_, err = tmpfile.Write(data[:strings.Index(string(data), "<sequence")])
+		parseio.ExitOnError(err)
+		parseio.ExitOnError(tmpfile.Close())
+		if _, err := VerifyFile(tmpfile.Name()); err == nil {
+			t.Errorf("Error: VerifyFile() of a truncated file expected an error")
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package uniprot
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopher-proteinlab/annotation"
+)
+
+// ToString converts the Entry to an XML-formatted string.
+func (e *Entry) ToString() string {
+	return XmlString(*e)
+}
+
+// RecordID returns the UniProt entry name, e.g. 1001R_ASFK5.
+func (e *Entry) RecordID() string {
+	return e.Name
+}
+
+// RecordAccessions returns the primary accession of the entry.
+func (e *Entry) RecordAccessions() []string {
+	if e.Accession == "" {
+		return nil
+	}
+	return []string{e.Accession}
+}
+
+// RecordDescription returns the recommended protein name, or the submitted name of an unreviewed entry.
+func (e *Entry) RecordDescription() string {
+	if name := e.Protein.RecommendedName.FullName.Value; name != "" {
+		return name
+	}
+	return e.Protein.SubmittedName.FullName.Value
+}
+
+// RecordOrganism returns the scientific name of the source organism.
+func (e *Entry) RecordOrganism() string {
+	for _, name := range e.Organism.Name {
+		if name.Type == "scientific" {
+			return name.Value
+		}
+	}
+	return ""
+}
+
+// RecordTaxonID returns the NCBI taxonomy identifier of the source organism, or 0 if there is none.
+func (e *Entry) RecordTaxonID() int {
+	for _, ref := range e.Organism.DBReference {
+		if ref.Type == "NCBI Taxonomy" {
+			if id, err := strconv.Atoi(ref.ID); err == nil {
+				return id
+			}
+		}
+	}
+	return 0
+}
+
+// RecordSequence returns the protein sequence of the entry.
+func (e *Entry) RecordSequence() string {
+	return e.Sequence.Value
+}
+
+// RecordFeatures converts the sequence annotations of the entry to features. The feature type
+// becomes the key and the description, identifier and evidence become /note, /id and /evidence
+// qualifiers. Features with an unknown position cannot be placed on the sequence and are skipped.
+func (e *Entry) RecordFeatures() ([]annotation.RecordFeature, error) {
+	features := make([]annotation.RecordFeature, 0, len(e.Feature))
+	for _, feature := range e.Feature {
+		location, err := feature.Location.ToLocation()
+		if err != nil {
+			continue
+		}
+		qualifiers := make(map[string]string)
+		if feature.Description != "" {
+			qualifiers["/note"] = feature.Description
+		}
+		if feature.ID != "" {
+			qualifiers["/id"] = feature.ID
+		}
+		if feature.Evidence != "" {
+			qualifiers["/evidence"] = feature.Evidence
+		}
+		features = append(features, annotation.RecordFeature{
+			Key:        feature.Type,
+			Location:   location,
+			Qualifiers: qualifiers,
+		})
+	}
+	return features, nil
+}
+
+// RecordCrossReferences returns the database cross-references of the entry.
+func (e *Entry) RecordCrossReferences() []annotation.CrossReference {
+	xrefs := make([]annotation.CrossReference, 0, len(e.DBReference))
+	for _, ref := range e.DBReference {
+		xrefs = append(xrefs, annotation.CrossReference{Database: ref.Type, ID: ref.ID})
+	}
+	return xrefs
+}
+
+// ToLocation converts a UniProt feature location to a single span. Positions with the "less than"
+// or "greater than" status become partial ends. Locations with an unknown or missing position
+// are returned as an error.
+func (l Location) ToLocation() (annotation.Location, error) {
+	begin, end := l.Begin, l.End
+	if l.Position != nil {
+		begin, end = l.Position, l.Position
+	}
+	if !knownPosition(begin) || !knownPosition(end) {
+		return annotation.Location{}, fmt.Errorf("location with an unknown position")
+	}
+	span := annotation.Span{
+		Start:        int(begin.Position),
+		End:          int(end.Position),
+		PartialStart: begin.Status == "less than",
+		PartialEnd:   end.Status == "greater than",
+	}
+	return annotation.Location{Spans: []annotation.Span{span}}, nil
+}
+
+// knownPosition reports whether a position is given, which it is not with the "unknown" status.
+func knownPosition(p *Position) bool {
+	return p != nil && p.Status != "unknown" && p.Position > 0
+}
+
+// IsProtein reports that UniProt entries hold protein sequences, so their features are in
+// protein coordinates.
+func (e *Entry) IsProtein() bool {
+	return true
+}
@@ -0,0 +1,93 @@
+package uniprot
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"gopher-proteinlab/annotation"
+	"gopher-proteinlab/parseio"
+)
+
+func TestEntryRecord(t *testing.T) {
+	xmlReader := parseio.NewCodeReader("testdata/uniprot.xml.gz")
+	defer xmlReader.Close()
+
+	entry, err := ParseUniProt(xml.NewDecoder(xmlReader))
+	if err != nil {
+		t.Fatalf("ParseUniProt failed: %v", err)
+	}
+	var record annotation.Record = entry
+
+	if record.RecordID() != "1001R_ASFK5" || !reflect.DeepEqual(record.RecordAccessions(), []string{"P0C9F0"}) {
+		t.Errorf("Error: RecordID() = %s, RecordAccessions() = %v", record.RecordID(), record.RecordAccessions())
+	}
+	if record.RecordDescription() != "Protein MGF 100-1R" {
+		t.Errorf("Error: RecordDescription() = %s, expected: Protein MGF 100-1R", record.RecordDescription())
+	}
+	if record.RecordOrganism() != "African swine fever virus (isolate Pig/Kenya/KEN-50/1950)" || record.RecordTaxonID() != 561445 {
+		t.Errorf("Error: RecordOrganism() = %s, RecordTaxonID() = %d", record.RecordOrganism(), record.RecordTaxonID())
+	}
+	if len(record.RecordSequence()) != 122 {
+		t.Errorf("Error: RecordSequence() has %d residues, expected: 122", len(record.RecordSequence()))
+	}
+	features, err := record.RecordFeatures()
+	expected := []annotation.RecordFeature{{
+		Key:        "chain",
+		Location:   annotation.Location{Spans: []annotation.Span{{Start: 1, End: 122}}},
+		Qualifiers: map[string]string{"/note": "Protein MGF 100-1R", "/id": "PRO_0000373170"},
+	}}
+	if err != nil || !reflect.DeepEqual(features, expected) {
+		t.Errorf("Error: RecordFeatures() = %+v, %v, expected: %+v", features, err, expected)
+	}
+	if xrefs := record.RecordCrossReferences(); len(xrefs) != len(entry.DBReference) || xrefs[0].Database != entry.DBReference[0].Type {
+		t.Errorf("Error: RecordCrossReferences() = %+v", xrefs)
+	}
+
+	partial := Location{Begin: &Position{Status: "less than", Position: 5}, End: &Position{Position: 9}}
+	if loc, err := partial.ToLocation(); err != nil || loc.Spans[0] != (annotation.Span{Start: 5, End: 9, PartialStart: true}) {
+		t.Errorf("Error: ToLocation() = %+v, %v", loc, err)
+	}
+
+	// Features with an unknown position are left out rather than exported as inverted spans
+	unknown := Location{Begin: &Position{Position: 5}, End: &Position{Status: "unknown"}}
+	if loc, err := unknown.ToLocation(); err == nil {
+		t.Errorf("Error: ToLocation() = %+v, expected an error for an unknown position", loc)
+	}
+	withUnknown := &Entry{Feature: []Feature{{Type: "chain", Location: unknown}, {Type: "site", Location: Location{Position: &Position{Position: 7}}}}}
+	if features, err := withUnknown.RecordFeatures(); err != nil || len(features) != 1 || features[0].Key != "site" {
+		t.Errorf("Error: RecordFeatures() = %+v, %v, expected only the site", features, err)
+	}
+}
+
+func TestEntryGFF(t *testing.T) {
+	xmlReader := parseio.NewCodeReader("testdata/uniprot.xml.gz")
+	defer xmlReader.Close()
+
+	entry, err := ParseUniProt(xml.NewDecoder(xmlReader))
+	if err != nil {
+		t.Fatalf("ParseUniProt failed: %v", err)
+	}
+	features, err := annotation.GFFFeatures(entry, "UniProtKB")
+	expected := "P0C9F0\tUniProtKB\tchain\t1\t122\t.\t.\t.\tID=PRO_0000373170;Note=Protein MGF 100-1R"
+	if err != nil || len(features) != 1 || features[0].ToString() != expected {
+		t.Errorf("Error: GFFFeatures() = %+v, %v, expected: %s", features, err, expected)
+	}
+}
+
+func TestEntryBED(t *testing.T) {
+	xmlReader := parseio.NewCodeReader("testdata/uniprot.xml.gz")
+	defer xmlReader.Close()
+
+	entry, err := ParseUniProt(xml.NewDecoder(xmlReader))
+	if err != nil {
+		t.Fatalf("ParseUniProt failed: %v", err)
+	}
+	beds, err := annotation.BEDFeatures(entry, "chain", "domain", "region")
+	if err != nil || len(beds) != 1 || beds[0].ToString(6) != "P0C9F0\t0\t122\tchain\t0\t." {
+		t.Errorf("Error: BEDFeatures() = %+v, %v", beds, err)
+	}
+	if beds, _ = annotation.BEDFeatures(entry, "domain"); len(beds) != 0 {
+		t.Errorf("Error: BEDFeatures() returned %d domains, expected: 0", len(beds))
+	}
+}
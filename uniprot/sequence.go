@@ -0,0 +1,35 @@
+package uniprot
+
+import (
+	"math"
+	"strings"
+
+	"gopher-proteinlab/protein"
+)
+
+// Proteins converts the sequence to Protein amino acids.
+func (s Sequence) Proteins() []protein.Protein {
+	return protein.ToProteins(strings.Join(strings.Fields(s.Value), ""))
+}
+
+// ParseProteins converts the sequence to Protein amino acids like Proteins, but reports invalid
+// residues in a *protein.InvalidResidueError rather than reading them as X.
+func (s Sequence) ParseProteins() ([]protein.Protein, error) {
+	return protein.Encode(strings.Join(strings.Fields(s.Value), ""), protein.EncodeOptions{FoldCase: true})
+}
+
+// ProtParam computes the physicochemical parameters of the sequence.
+func (s Sequence) ProtParam() protein.ProtParam {
+	return protein.Analyze(s.Proteins())
+}
+
+// ComputedMass returns the average mass of the sequence rounded to daltons, the way UniProt reports
+// it in the mass attribute.
+func (s Sequence) ComputedMass() int {
+	return int(math.Round(protein.MolecularWeight(s.Proteins())))
+}
+
+// Digest cleaves the sequence of the entry with an enzyme.
+func (e *Entry) Digest(enzyme protein.Enzyme, opts protein.DigestOptions) []protein.Peptide {
+	return protein.Digest(e.Sequence.Proteins(), enzyme, opts)
+}
@@ -0,0 +1,59 @@
+package uniprot
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"gopher-proteinlab/parseio"
+	"gopher-proteinlab/protein"
+)
+
+func TestSequenceMass(t *testing.T) {
+	xmlReader := parseio.NewCodeReader("testdata/uniprot.xml.gz")
+	defer xmlReader.Close()
+
+	entry, err := ParseUniProt(xml.NewDecoder(xmlReader))
+	if err != nil {
+		t.Fatalf("ParseUniProt failed: %v", err)
+	}
+	if mass := entry.Sequence.ComputedMass(); mass != entry.Sequence.Mass {
+		t.Errorf("Error: ComputedMass() = %d, expected: %d", mass, entry.Sequence.Mass)
+	}
+	if params := entry.Sequence.ProtParam(); params.Length != entry.Sequence.Length || params.Composition[protein.Lys] != 16 {
+		t.Errorf("Error: ProtParam() = %+v", params)
+	}
+
+	if residues, err := entry.Sequence.ParseProteins(); err != nil || protein.ToString(residues) != protein.ToString(entry.Sequence.Proteins()) {
+		t.Errorf("Error: ParseProteins() = %s, %v", protein.ToString(residues), err)
+	}
+	lower := Sequence{Value: "mvr\nlfy"}
+	if residues, err := lower.ParseProteins(); err != nil || protein.ToString(residues) != "MVRLFY" {
+		t.Errorf("Error: ParseProteins() of lower case residues = %s, %v", protein.ToString(residues), err)
+	}
+	invalid := Sequence{Value: "MVR LF1"}
+	if _, err := invalid.ParseProteins(); err == nil || err.Error() != "1 invalid amino acid symbols: '1' at 6" {
+		t.Errorf("Error: ParseProteins() = %v, expected an invalid residue error", err)
+	}
+}
+
+func TestEntryDigest(t *testing.T) {
+	xmlReader := parseio.NewCodeReader("testdata/uniprot.xml.gz")
+	defer xmlReader.Close()
+
+	entry, err := ParseUniProt(xml.NewDecoder(xmlReader))
+	if err != nil {
+		t.Fatalf("ParseUniProt failed: %v", err)
+	}
+	// Without missed cleavages the peptides tile the sequence
+	peptides := entry.Digest(protein.Enzymes["trypsin"], protein.DigestOptions{})
+	end := 0
+	for _, p := range peptides {
+		if p.Start != end+1 || protein.ToString(p.Sequence) != entry.Sequence.Value[p.Start-1:p.End] {
+			t.Errorf("Error: Digest() peptide %+v does not follow position %d", p, end)
+		}
+		end = p.End
+	}
+	if end != entry.Sequence.Length || protein.ToString(peptides[0].Sequence) != "MVR" {
+		t.Errorf("Error: Digest() peptides end at %d, first %s", end, protein.ToString(peptides[0].Sequence))
+	}
+}